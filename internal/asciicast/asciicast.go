@@ -0,0 +1,164 @@
+// Package asciicast writes and plays terminal recordings
+// in the asciicast v2 format.
+//
+// https://docs.asciinema.org/manual/asciicast/v2/
+package asciicast
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	Version = 2
+
+	// EventOutput is data written to the terminal.
+	EventOutput = "o"
+	// EventInput is data read from the terminal.
+	EventInput = "i"
+)
+
+// Header is the first line of a recording.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is a single line of a recording following the header.
+type Event struct {
+	// Time is the duration since the start of the recording.
+	Time time.Duration
+	Type string
+	Data string
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time.Seconds(), e.Type, e.Data})
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var v []interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v) != 3 {
+		return errors.Errorf("invalid event: expected 3 elements, got %d", len(v))
+	}
+
+	seconds, ok := v[0].(float64)
+	if !ok {
+		return errors.New("invalid event: time is not a number")
+	}
+	e.Time = time.Duration(seconds * float64(time.Second))
+
+	if e.Type, ok = v[1].(string); !ok {
+		return errors.New("invalid event: type is not a string")
+	}
+	if e.Data, ok = v[2].(string); !ok {
+		return errors.New("invalid event: data is not a string")
+	}
+
+	return nil
+}
+
+// Writer records data written to it as output events. It's safe
+// for concurrent use. Multi-byte characters split across writes
+// are kept until they are complete as event data must be valid UTF-8.
+type Writer struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	start   time.Time
+	pending []byte
+	err     error
+	now     func() time.Time
+}
+
+// NewWriter writes the header to w and returns a Writer
+// for the following events. If header.Timestamp is zero,
+// the current time is used.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	return newWriter(w, header, time.Now)
+}
+
+func newWriter(w io.Writer, header Header, now func() time.Time) (*Writer, error) {
+	start := now()
+
+	header.Version = Version
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(header); err != nil {
+		return nil, errors.Wrap(err, "failed to write asciicast header")
+	}
+
+	return &Writer{enc: enc, start: start, now: now}, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	data := append(w.pending, p...)
+
+	// Find the end of the last complete character.
+	end := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+
+	w.pending = append([]byte(nil), data[end:]...)
+
+	if end > 0 {
+		w.err = w.writeEvent(EventOutput, data[:end])
+	}
+
+	return len(p), w.err
+}
+
+func (w *Writer) writeEvent(typ string, data []byte) error {
+	event := Event{
+		Time: w.now().Sub(w.start),
+		Type: typ,
+		Data: strings.ToValidUTF8(string(data), string(utf8.RuneError)),
+	}
+	err := w.enc.Encode(event)
+	return errors.Wrap(err, "failed to write asciicast event")
+}
+
+// Close writes data of an incomplete character, if any.
+// It does not close the underlying writer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err == nil && len(w.pending) > 0 {
+		w.err = w.writeEvent(EventOutput, w.pending)
+		w.pending = nil
+	}
+
+	return w.err
+}
@@ -0,0 +1,83 @@
+package asciicast
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		now = time.Unix(1675000000, 0)
+	)
+
+	w, err := newWriter(&buf, Header{Width: 80, Height: 24, Command: "echo"}, func() time.Time { return now })
+	require.NoError(t, err)
+
+	now = now.Add(1500 * time.Millisecond)
+	_, err = w.Write([]byte("hello\r\n"))
+	require.NoError(t, err)
+
+	// "ł" is encoded as 0xc5 0x82 and split across writes.
+	now = now.Add(time.Second)
+	_, err = w.Write([]byte{'a', 0xc5})
+	require.NoError(t, err)
+	_, err = w.Write([]byte{0x82, '"'})
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte{0xc5})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(
+		t,
+		`{"version":2,"width":80,"height":24,"timestamp":1675000000,"command":"echo"}`+"\n"+
+			`[1.5,"o","hello\r\n"]`+"\n"+
+			`[2.5,"o","a"]`+"\n"+
+			`[2.5,"o","ł\""]`+"\n"+
+			"[2.5,\"o\",\"\ufffd\"]\n",
+		buf.String(),
+	)
+}
+
+func TestPlay(t *testing.T) {
+	recording := `{"version": 2, "width": 80, "height": 24}` + "\n" +
+		`[0.1, "o", "hello "]` + "\n" +
+		`[0.2, "i", "x"]` + "\n" +
+		`[5.0, "o", "world\r\n"]` + "\n"
+
+	var buf bytes.Buffer
+
+	start := time.Now()
+	err := Play(context.Background(), strings.NewReader(recording), &buf, PlayOptions{Speed: 10, MaxIdle: time.Second})
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello world\r\n", buf.String())
+	// 0.1s + 0.1s + 1s (limited by MaxIdle) at speed 10.
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestPlay_Invalid(t *testing.T) {
+	err := Play(context.Background(), strings.NewReader(`{"version": 1}`), &bytes.Buffer{}, PlayOptions{})
+	assert.EqualError(t, err, "unsupported asciicast version 1")
+
+	err = Play(context.Background(), strings.NewReader(`{"version": 2}`+"\n"+`[0.1, "o"]`), &bytes.Buffer{}, PlayOptions{})
+	assert.EqualError(t, err, "invalid asciicast event in line 2: invalid event: expected 3 elements, got 2")
+}
+
+func TestPlay_Canceled(t *testing.T) {
+	recording := `{"version": 2, "width": 80, "height": 24}` + "\n" +
+		`[10, "o", "late"]` + "\n"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := Play(ctx, strings.NewReader(recording), &bytes.Buffer{}, PlayOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
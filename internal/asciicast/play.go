@@ -0,0 +1,97 @@
+package asciicast
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type PlayOptions struct {
+	// Speed multiplies the playback speed. If zero, 1 is used.
+	Speed float64
+	// MaxIdle, if positive, limits pauses between events.
+	MaxIdle time.Duration
+}
+
+// ReadHeader reads the header of the recording from r.
+func ReadHeader(r *bufio.Reader) (Header, error) {
+	var header Header
+
+	line, err := r.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return header, errors.Wrap(err, "failed to read asciicast header")
+	}
+
+	if err := json.Unmarshal(line, &header); err != nil {
+		return header, errors.Wrap(err, "invalid asciicast header")
+	}
+	if header.Version != Version {
+		return header, errors.Errorf("unsupported asciicast version %d", header.Version)
+	}
+
+	return header, nil
+}
+
+// Play writes output events of the recording read from r to w
+// keeping their timing. It returns when the recording ends
+// or ctx is done.
+func Play(ctx context.Context, r io.Reader, w io.Writer, opts PlayOptions) error {
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	br := bufio.NewReader(r)
+
+	if _, err := ReadHeader(br); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	var last time.Duration
+
+	for lineNo := 2; ; lineNo++ {
+		line, err := br.ReadBytes('\n')
+		if len(line) == 0 && errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return errors.Wrap(err, "failed to read asciicast event")
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return errors.Wrapf(err, "invalid asciicast event in line %d", lineNo)
+		}
+
+		delay := event.Time - last
+		last = event.Time
+		if opts.MaxIdle > 0 && delay > opts.MaxIdle {
+			delay = opts.MaxIdle
+		}
+
+		if delay > 0 {
+			timer.Reset(time.Duration(float64(delay) / speed))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if event.Type != EventOutput {
+			continue
+		}
+
+		if _, err := io.WriteString(w, event.Data); err != nil {
+			return errors.Wrap(err, "failed to write output")
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/document"
+)
+
+const (
+	defaultStopTimeout = 10 * time.Second
+	logsPollInterval   = 200 * time.Millisecond
+)
+
+// isBackground reports whether the block should be started
+// in background when run with "runme run".
+func isBackground(block *document.CodeBlock) bool {
+	value, _ := strconv.ParseBool(block.Attributes()["background"])
+	return value
+}
+
+// backgroundProcess is a command started in background with "runme start"
+// or by "runme run" if it has the background attribute. Its pid and output
+// are stored in the project's state dir.
+type backgroundProcess struct {
+	name string
+	dir  string
+}
+
+func getBackgroundProcess(name string) (*backgroundProcess, error) {
+	stateDir, err := getProjectStateDir()
+	if err != nil {
+		return nil, err
+	}
+	return &backgroundProcess{name: name, dir: filepath.Join(stateDir, "background")}, nil
+}
+
+// listBackgroundProcesses returns processes which have a pid file.
+func listBackgroundProcesses() ([]*backgroundProcess, error) {
+	stateDir, err := getProjectStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(stateDir, "background")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrap(err, "failed to read background dir")
+	}
+
+	var result []*backgroundProcess
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".pid") {
+			name := unescapeFileName(strings.TrimSuffix(entry.Name(), ".pid"))
+			result = append(result, &backgroundProcess{name: name, dir: dir})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+
+	return result, nil
+}
+
+func (p *backgroundProcess) pidFile() string {
+	return filepath.Join(p.dir, escapeFileName(p.name)+".pid")
+}
+
+func (p *backgroundProcess) LogFile() string {
+	return filepath.Join(p.dir, escapeFileName(p.name)+".log")
+}
+
+// Pid returns the pid of the process or zero
+// if it has never been started or was stopped.
+func (p *backgroundProcess) Pid() (int, error) {
+	data, err := os.ReadFile(p.pidFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read pid of %q", p.name)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, errors.Wrapf(err, "invalid pid file of %q", p.name)
+}
+
+// Running returns the pid of the process if it's running.
+func (p *backgroundProcess) Running() (int, bool, error) {
+	pid, err := p.Pid()
+	if err != nil || pid == 0 {
+		return 0, false, err
+	}
+	return pid, processRunning(pid), nil
+}
+
+// StartedAt returns the time when the process was started.
+func (p *backgroundProcess) StartedAt() (time.Time, error) {
+	info, err := os.Stat(p.pidFile())
+	if err != nil {
+		return time.Time{}, errors.WithStack(err)
+	}
+	return info.ModTime(), nil
+}
+
+// Start starts the command in a new process group,
+// detached from the current process, with its output
+// redirected to the log file. Blocks run in the same group
+// so that Stop can kill them together with runme.
+func (p *backgroundProcess) Start(opts *runCmdOpts) (int, error) {
+	if pid, ok, err := p.Running(); err != nil {
+		return 0, err
+	} else if ok {
+		return 0, errors.Errorf("command %q is already running (pid %d)", p.name, pid)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to find runme executable")
+	}
+
+	if err := os.MkdirAll(p.dir, 0o700); err != nil {
+		return 0, errors.Wrap(err, "failed to create background dir")
+	}
+
+	var varsFile string
+	if len(opts.vars) > 0 {
+		varsFile, err = writeVarsFile(p.dir, opts.vars)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	args, err := p.runArgs(opts, varsFile)
+	if err != nil {
+		_ = os.Remove(varsFile)
+		return 0, err
+	}
+
+	logFile, err := os.Create(p.LogFile())
+	if err != nil {
+		_ = os.Remove(varsFile)
+		return 0, errors.Wrap(err, "failed to create log file")
+	}
+	defer func() { _ = logFile.Close() }()
+
+	c := exec.Command(executable, args...)
+	c.Stdout = logFile
+	c.Stderr = logFile
+	c.SysProcAttr = detachedProcAttr()
+
+	if err := c.Start(); err != nil {
+		_ = os.Remove(varsFile)
+		return 0, errors.Wrapf(err, "failed to start command %q", p.name)
+	}
+
+	pid := c.Process.Pid
+
+	if err := os.WriteFile(p.pidFile(), []byte(strconv.Itoa(pid)), 0o600); err != nil {
+		_ = killProcessGroup(pid, true)
+		_ = os.Remove(varsFile)
+		return 0, errors.Wrap(err, "failed to write pid file")
+	}
+
+	return pid, errors.WithStack(c.Process.Release())
+}
+
+// runArgs returns arguments of "runme run" which runs the command
+// in foreground in the detached process. Values of variables are
+// passed in varsFile, if not empty, as arguments are visible to others.
+func (p *backgroundProcess) runArgs(opts *runCmdOpts, varsFile string) ([]string, error) {
+	chdir, err := filepath.Abs(fChdir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Commands are approved and confirmed before starting the detached process.
+	args := []string{"run", "--chdir", chdir, "--filename", fFileName, "--foreground", "--non-interactive", "--yes", "--detached"}
+	if fAllowUnknown {
+		args = append(args, "--allow-unknown")
+	}
+	if opts.noDeps {
+		args = append(args, "--no-deps")
+	}
+	if opts.session != "" {
+		args = append(args, "--session", opts.session)
+	}
+	if varsFile != "" {
+		args = append(args, "--vars-file", varsFile)
+	}
+	for _, script := range opts.replaceScripts {
+		args = append(args, "--replace", script)
+	}
+	if opts.sandbox {
+		args = append(args, "--sandbox")
+	}
+	if opts.sandboxNetwork {
+		args = append(args, "--sandbox-network")
+	}
+	if opts.redact {
+		args = append(args, "--redact")
+	}
+
+	return append(args, "--", p.name), nil
+}
+
+// Stop terminates the process group and waits up to timeout for
+// the process to exit before killing it. The pid file is removed.
+func (p *backgroundProcess) Stop(ctx context.Context, timeout time.Duration) (bool, error) {
+	pid, running, err := p.Running()
+	if err != nil {
+		return false, err
+	}
+
+	if running {
+		if err := p.terminate(ctx, pid, timeout); err != nil {
+			return false, err
+		}
+	}
+
+	if err := os.Remove(p.pidFile()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, errors.Wrap(err, "failed to remove pid file")
+	}
+
+	return running, nil
+}
+
+func (p *backgroundProcess) terminate(ctx context.Context, pid int, timeout time.Duration) error {
+	if err := killProcessGroup(pid, false); err != nil {
+		return errors.Wrapf(err, "failed to stop command %q", p.name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(logsPollInterval)
+	defer ticker.Stop()
+
+	for processRunning(pid) {
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(killProcessGroup(pid, true), "failed to kill command %q", p.name)
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// WriteLogs writes the log file to w. If follow is true, it keeps
+// writing new data until the process exits or ctx is done.
+func (p *backgroundProcess) WriteLogs(ctx context.Context, w io.Writer, follow bool) error {
+	f, err := os.Open(p.LogFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.Errorf("no logs of command %q", p.name)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to open log file")
+	}
+	defer func() { _ = f.Close() }()
+
+	for {
+		if _, err := io.Copy(w, f); err != nil {
+			return errors.Wrap(err, "failed to read log file")
+		}
+
+		if !follow {
+			return nil
+		}
+
+		// Read the remaining data after the process exited.
+		if _, running, err := p.Running(); err != nil || !running {
+			_, cErr := io.Copy(w, f)
+			if err == nil {
+				err = cErr
+			}
+			return errors.WithStack(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
+
+// resolveBackgroundVariables resolves variables of blocks which are run
+// by a detached process, asking for missing values if possible, as the
+// detached process can't ask. The values are added to opts.vars.
+func resolveBackgroundVariables(plan document.CodeBlocks, opts *runCmdOpts, streams blockStreams) error {
+	for _, block := range plan {
+		env, _, err := loadBlockEnv(block, opts)
+		if err != nil {
+			return err
+		}
+		if _, err := resolveVariables(block, env, opts, streams); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeFileName(name string) string {
+	return strings.NewReplacer("%", "%25", "/", "%2F", `\`, "%5C").Replace(name)
+}
+
+func unescapeFileName(name string) string {
+	return strings.NewReplacer("%25", "%", "%2F", "/", "%5C", `\`).Replace(name)
+}
+
+func printStarted(w io.Writer, name string, pid int) {
+	_, _ = fmt.Fprintf(w, "runme: started %q in background (pid %d); see \"runme logs %s\"\n", name, pid, name)
+}
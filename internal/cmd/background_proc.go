@@ -0,0 +1,52 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// detachedProcAttr makes the process a leader of a new session
+// so that it doesn't receive signals sent to the terminal
+// and its whole process group can be stopped.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+func processRunning(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+
+	// A zombie process has exited but has not been reaped yet,
+	// for example, by runme which started it. It can be detected
+	// only on systems with procfs.
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		// The state follows the executable name in parentheses.
+		if idx := bytes.LastIndexByte(data, ')'); idx >= 0 && idx+2 < len(data) {
+			return data[idx+2] != 'Z'
+		}
+	}
+
+	return true
+}
+
+// killProcessGroup sends SIGTERM, or SIGKILL if force is true,
+// to the process group led by pid.
+func killProcessGroup(pid int, force bool) error {
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	err := syscall.Kill(-pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return errors.WithStack(err)
+}
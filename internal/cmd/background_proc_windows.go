@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+func processRunning(pid int) bool {
+	// FindProcess opens a handle to the process
+	// and fails if it doesn't exist.
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
+
+// killProcessGroup kills the process. Windows does not support
+// graceful termination hence force is ignored.
+func killProcessGroup(pid int, force bool) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	return errors.WithStack(p.Kill())
+}
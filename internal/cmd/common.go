@@ -3,6 +3,8 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/config"
 	"github.com/stateful/runme/internal/document"
 	"github.com/stateful/runme/internal/renderer/cmark"
 	"github.com/stateful/runme/internal/runner"
@@ -22,6 +25,8 @@ func readMarkdownFile(args []string) ([]byte, error) {
 	arg := ""
 	if len(args) == 1 {
 		arg = args[0]
+	} else if isRemoteMarkdown() {
+		arg = fFileName
 	}
 
 	if arg == "" {
@@ -80,6 +85,11 @@ func readMarkdownFile(args []string) ([]byte, error) {
 	return data, nil
 }
 
+// isRemoteMarkdown reports whether --filename is a URL.
+func isRemoteMarkdown() bool {
+	return strings.HasPrefix(fFileName, "https://")
+}
+
 func writeMarkdownFile(args []string, data []byte) error {
 	arg := ""
 	if len(args) == 1 {
@@ -107,7 +117,42 @@ func getCodeBlocks() (document.CodeBlocks, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseCodeBlocks(data)
+}
+
+// getDocument returns code blocks and the front matter of the markdown
+// file. It's used by commands running blocks so that the file is read
+// only once.
+func getDocument() (document.CodeBlocks, document.Frontmatter, error) {
+	data, err := readMarkdownFile(nil)
+	if err != nil {
+		return nil, document.Frontmatter{}, err
+	}
+	return parseDocument(data)
+}
+
+func parseDocument(data []byte) (document.CodeBlocks, document.Frontmatter, error) {
+	blocks, err := parseCodeBlocks(data)
+	if err != nil {
+		return nil, document.Frontmatter{}, err
+	}
 
+	sections, err := document.ParseSections(data)
+	if err != nil {
+		return nil, document.Frontmatter{}, err
+	}
+
+	// Front matters are also used by other tools and
+	// invalid settings only make runme ignore them.
+	fmatter, err := document.ParseFrontmatter(sections.FrontMatter)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "runme: ignoring front matter settings: %s\n", err)
+	}
+
+	return blocks, fmatter, nil
+}
+
+func parseCodeBlocks(data []byte) (document.CodeBlocks, error) {
 	doc := document.New(data, cmark.Render)
 	node, _, err := doc.Parse()
 	if err != nil {
@@ -116,9 +161,18 @@ func getCodeBlocks() (document.CodeBlocks, error) {
 
 	blocks := document.CollectCodeBlocks(node)
 
+	registry, err := getRegistry()
+	if err != nil {
+		return nil, err
+	}
+
 	filtered := make(document.CodeBlocks, 0, len(blocks))
 	for _, b := range blocks {
-		if fAllowUnknown || (b.Language() != "" && runner.IsSupported(b.Language())) {
+		// Output blocks contain expected output of other blocks.
+		if b.OutputOf() != "" {
+			continue
+		}
+		if fAllowUnknown || (b.Language() != "" && registry.IsSupported(b.Language())) {
 			filtered = append(filtered, b)
 		}
 	}
@@ -193,4 +247,49 @@ func getDefaultConfigHome() string {
 	return filepath.Join(dir, ".config", "stateful")
 }
 
+// projectID identifies the project by its directory.
+// It's used to keep state of different projects separately.
+func projectID() (string, error) {
+	dir, err := filepath.Abs(fChdir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	sum := sha256.Sum256([]byte(dir))
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// getProjectStateDir returns a directory
+// for the project's state, like logs.
+func getProjectStateDir() (string, error) {
+	id, err := projectID()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(getDefaultConfigHome(), "runme", "projects", id), nil
+}
+
+// getConfig loads the user config followed by the project config
+// which takes precedence.
+func getConfig() (*config.Config, error) {
+	return config.Load(
+		filepath.Join(getDefaultConfigHome(), "runme.yaml"),
+		filepath.Join(fChdir, config.FileName),
+	)
+}
+
+// getRegistry returns the built-in executables
+// extended by interpreters from the config.
+func getRegistry() (*runner.Registry, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	registry := runner.NewRegistry()
+	for lang, interpreter := range cfg.Interpreters {
+		registry.RegisterInterpreter(lang, interpreter)
+	}
+	return registry, nil
+}
+
 type runFunc func(context.Context) error
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/danger"
+	"github.com/stateful/runme/internal/document"
+)
+
+// getDangerDetector returns a detector using the built-in
+// rules extended by rules from the config.
+func getDangerDetector() (*danger.Detector, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	return danger.NewDetector(append(danger.DefaultRules[:len(danger.DefaultRules):len(danger.DefaultRules)], cfg.Danger...)...)
+}
+
+// requiresConfirmation reports whether the block must be confirmed before
+// running. It's true if the block has the "confirm=true" attribute or its
+// script matches a danger rule. "confirm=false" does not skip the rules;
+// only --yes and the config can.
+func requiresConfirmation(block *document.CodeBlock, detector *danger.Detector, replaceScripts []string) (bool, []danger.Match, error) {
+	confirm := false
+
+	if v := block.Attributes()["confirm"]; v != "" {
+		var err error
+		confirm, err = strconv.ParseBool(v)
+		if err != nil {
+			return false, nil, errors.Errorf("invalid confirm %q of command %q: expected true or false", v, block.Name())
+		}
+	}
+
+	script, err := finalScript(block, replaceScripts)
+	if err != nil {
+		return false, nil, err
+	}
+
+	matches := detector.Check(strings.Split(script, "\n"))
+
+	return confirm || len(matches) > 0, matches, nil
+}
+
+// confirmBlocks asks the user to confirm running blocks which require it.
+// Unless opts.yes is true, an error is returned if stdin is not a terminal,
+// opts.nonInteractive is true, or the user declines.
+func confirmBlocks(cmd *cobra.Command, plan document.CodeBlocks, opts *runCmdOpts) error {
+	if opts.yes {
+		return nil
+	}
+
+	detector, err := getDangerDetector()
+	if err != nil {
+		return err
+	}
+
+	for _, block := range plan {
+		required, matches, err := requiresConfirmation(block, detector, opts.replaceScripts)
+		if err != nil {
+			return err
+		}
+		if !required {
+			continue
+		}
+
+		if opts.nonInteractive || !isInteractive(cmd.InOrStdin()) {
+			return errors.Errorf("command %q requires confirmation; use --yes to run it", block.Name())
+		}
+
+		stderr := cmd.ErrOrStderr()
+		if len(matches) == 0 {
+			_, _ = fmt.Fprintf(stderr, "Command %q requires confirmation.\n", block.Name())
+		} else {
+			_, _ = fmt.Fprintf(stderr, "Command %q might be destructive:\n", block.Name())
+			for _, m := range matches {
+				reason := m.Rule.Description
+				if reason == "" {
+					reason = m.Rule.Name
+				}
+				_, _ = fmt.Fprintf(stderr, "  line %d: %s (%s)\n", m.Line, m.Text, reason)
+			}
+		}
+		_, _ = fmt.Fprintln(stderr)
+
+		ok, err := promptForConfirmation(cmd, fmt.Sprintf("Do you want to run %q?", block.Name()))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.Errorf("command %q was not confirmed", block.Name())
+		}
+	}
+
+	return nil
+}
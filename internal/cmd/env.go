@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/runner"
+)
+
+// markdownDir returns the directory of the markdown file.
+// Relative paths in attributes and front matter are resolved against it.
+// For remote files, it's --chdir.
+func markdownDir() string {
+	if isRemoteMarkdown() {
+		return fChdir
+	}
+	return filepath.Dir(filepath.Join(fChdir, fFileName))
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// blockDir returns the working directory of the block. It's set by
+// the "cwd" attribute, the session, or the front matter and defaults to --chdir.
+func blockDir(block *document.CodeBlock, fmatter document.Frontmatter, session *runner.Session) string {
+	if cwd := block.Attributes()["cwd"]; cwd != "" {
+		return resolvePath(markdownDir(), cwd)
+	}
+	if session != nil && session.Dir != "" {
+		return session.Dir
+	}
+	if fmatter.Cwd != "" {
+		return resolvePath(markdownDir(), fmatter.Cwd)
+	}
+	return fChdir
+}
+
+// blockEnv returns the environment of the block. The base environment,
+// usually the current process's one, is extended, in order of precedence, by the "env" attribute
+// containing comma-separated KEY=VALUE pairs, the "env-file" attribute,
+// the session's changes, including unset variables, and the env and
+// env-file properties of the front matter.
+func blockEnv(base []string, block *document.CodeBlock, fmatter document.Frontmatter, session *runner.Session) ([]string, error) {
+	env := base
+
+	if fmatter.EnvFile != "" {
+		vars, err := runner.ReadEnvFile(resolvePath(markdownDir(), fmatter.EnvFile))
+		if err != nil {
+			return nil, err
+		}
+		env = runner.MergeEnv(env, vars...)
+	}
+
+	for _, key := range sortedKeys(fmatter.Env) {
+		env = runner.MergeEnv(env, key+"="+fmatter.Env[key])
+	}
+
+	if session != nil {
+		env = session.ApplyEnv(env)
+	}
+
+	attrs := block.Attributes()
+
+	if path := attrs["env-file"]; path != "" {
+		vars, err := runner.ReadEnvFile(resolvePath(markdownDir(), path))
+		if err != nil {
+			return nil, err
+		}
+		env = runner.MergeEnv(env, vars...)
+	}
+
+	if vars := attrs["env"]; vars != "" {
+		for _, kv := range strings.Split(vars, ",") {
+			if !strings.Contains(kv, "=") {
+				return nil, errors.Errorf("invalid env %q of command %q: expected comma-separated KEY=VALUE pairs", vars, block.Name())
+			}
+			env = runner.MergeEnv(env, kv)
+		}
+	}
+
+	return env, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
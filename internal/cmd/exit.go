@@ -0,0 +1,11 @@
+package cmd
+
+// ExitCode returns the code the process should exit with
+// when a command returns err. If err was caused by a failed
+// code block, its exit code is returned.
+func ExitCode(err error) int {
+	if code := exitCode(nil, err); code > 0 {
+		return code
+	}
+	return 1
+}
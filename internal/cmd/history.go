@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/cli/v2/pkg/iostreams"
+	"github.com/cli/cli/v2/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/history"
+	"github.com/stateful/runme/internal/runner"
+)
+
+func getHistoryStore() *history.Store {
+	return history.NewStore(filepath.Join(getDefaultConfigHome(), "runme", "history.jsonl"))
+}
+
+// recordHistory appends an execution of the block to the history.
+// Failing to do so does not fail the command, only a warning is printed
+// to the standard error, which is not a part of the block's output.
+func recordHistory(block *document.CodeBlock, dir string, opts *runCmdOpts, exitCode int, start time.Time) {
+	file, err := filepath.Abs(filepath.Join(fChdir, fFileName))
+	if err == nil {
+		err = getHistoryStore().Append(history.Entry{
+			Time:     start,
+			File:     file,
+			Name:     block.Name(),
+			Script:   strings.Join(block.Lines(), "\n"),
+			Replace:  opts.replaceScripts,
+			Session:  opts.session,
+			Dir:      dir,
+			ExitCode: exitCode,
+			Duration: time.Since(start),
+		})
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "runme: failed to record history: %s\n", err)
+	}
+}
+
+func historyCmd() *cobra.Command {
+	var formatJSON bool
+
+	cmd := cobra.Command{
+		Use:   "history",
+		Short: "List executed commands.",
+		Long: `List commands executed with runme from the oldest to the newest.
+
+Each entry contains the executed script after replacements, the working
+directory, and the exit code. Use "runme history rerun ID" to run
+a command again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := getHistoryStore().List()
+			if err != nil {
+				return err
+			}
+
+			if formatJSON {
+				if entries == nil {
+					entries = []history.Entry{}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return errors.Wrap(enc.Encode(entries), "failed to encode to JSON")
+			}
+
+			// TODO: this should be taken from cmd.
+			io := iostreams.System()
+			//lint:ignore SA1019 utils is deprecated but that's ok for now.
+			table := utils.NewTablePrinter(io)
+
+			table.AddField(strings.ToUpper("ID"), nil, nil)
+			table.AddField(strings.ToUpper("Time"), nil, nil)
+			table.AddField(strings.ToUpper("Name"), nil, nil)
+			table.AddField(strings.ToUpper("Exit Code"), nil, nil)
+			table.AddField(strings.ToUpper("Duration"), nil, nil)
+			table.AddField(strings.ToUpper("File"), nil, nil)
+			table.EndRow()
+
+			for _, e := range entries {
+				table.AddField(strconv.Itoa(e.ID), nil, nil)
+				table.AddField(e.Time.Local().Format(time.RFC3339), nil, nil)
+				table.AddField(e.Name, nil, nil)
+				table.AddField(strconv.Itoa(e.ExitCode), nil, nil)
+				table.AddField(e.Duration.Round(time.Millisecond).String(), nil, nil)
+				table.AddField(e.File, nil, nil)
+				table.EndRow()
+			}
+
+			return errors.Wrap(table.Render(), "failed to render")
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVar(&formatJSON, "json", false, "Print out entries as JSON.")
+
+	cmd.AddCommand(historyRerunCmd())
+
+	return &cmd
+}
+
+func historyRerunCmd() *cobra.Command {
+	opts := runCmdOpts{}
+
+	cmd := cobra.Command{
+		Use:   "rerun ID",
+		Short: "Run a command from the history again.",
+		Long: `Run a command from the history again.
+
+The command is looked up by its name in the markdown file it was run from
+and executed with the same replacements and session. Commands it needs
+are not run.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return errors.Errorf("invalid history entry ID %q", args[0])
+			}
+
+			entry, err := getHistoryStore().Get(id)
+			if err != nil {
+				return err
+			}
+
+			fChdir, fFileName = filepath.Split(entry.File)
+
+			blocks, fmatter, err := getDocument()
+			if err != nil {
+				return err
+			}
+			opts.frontmatter = fmatter
+
+			block, err := lookupCodeBlock(blocks, entry.Name)
+			if err != nil {
+				return err
+			}
+
+			opts.replaceScripts = entry.Replace
+			opts.session = entry.Session
+
+			opts.vars, err = parseVars(opts.varPairs)
+			if err != nil {
+				return err
+			}
+
+			if !opts.dryRun {
+				if err := checkTrust(cmd, document.CodeBlocks{block}, &opts); err != nil {
+					return err
+				}
+				if err := confirmBlocks(cmd, document.CodeBlocks{block}, &opts); err != nil {
+					return err
+				}
+			}
+
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "runme: running %q from %s\n", entry.Name, entry.File)
+
+			ctx, cancel := ctxWithSigCancel(cmd.Context())
+			defer cancel()
+
+			_, err = runBlock(ctx, block, &opts, cmdStreams(cmd))
+			return err
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Print the final command without executing.")
+	cmd.Flags().DurationVar(&opts.gracePeriod, "grace-period", runner.DefaultGracePeriod, "Time given to commands to exit after an interrupt or timeout before they are killed.")
+	cmd.Flags().StringArrayVar(&opts.varPairs, "var", nil, "Set a value of a placeholder or variable as KEY=VALUE.")
+	cmd.Flags().BoolVar(&opts.nonInteractive, "non-interactive", false, "Fail instead of asking to approve new or changed commands.")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "Run the command without asking for confirmation.")
+	cmd.Flags().BoolVar(&opts.redact, "redact", false, "Mask secrets, like tokens and passwords, in the output.")
+
+	return &cmd
+}
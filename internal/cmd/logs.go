@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func logsCmd() *cobra.Command {
+	var follow bool
+
+	cmd := cobra.Command{
+		Use:               "logs",
+		Short:             "Print logs of a command started in background.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validCmdNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			proc, err := getBackgroundProcess(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := ctxWithSigCancel(cmd.Context())
+			defer cancel()
+
+			return proc.WriteLogs(ctx, cmd.OutOrStdout(), follow)
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow the output until the command exits.")
+
+	return &cmd
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/asciicast"
+)
+
+func playCmd() *cobra.Command {
+	var opts asciicast.PlayOptions
+
+	cmd := cobra.Command{
+		Use:   "play FILE",
+		Short: "Replay a recording made with \"runme run --record\".",
+		Long: `Replay a recording in the asciicast v2 format in the terminal.
+
+Pauses longer than --max-idle are shortened to it. Use --speed
+to play the recording faster or slower.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Speed <= 0 {
+				return errors.New("--speed must be positive")
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return errors.Wrap(err, "failed to open recording")
+			}
+			defer func() { _ = f.Close() }()
+
+			ctx, cancel := ctxWithSigCancel(cmd.Context())
+			defer cancel()
+
+			err = asciicast.Play(ctx, f, cmd.OutOrStdout(), opts)
+			if errors.Is(err, ctx.Err()) {
+				return nil
+			}
+			return err
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().Float64Var(&opts.Speed, "speed", 1, "Playback speed multiplier.")
+	cmd.Flags().DurationVar(&opts.MaxIdle, "max-idle", 2*time.Second, "Limit pauses between outputs to this duration. Zero means no limit.")
+
+	return &cmd
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// prefixedOutput multiplexes output of concurrently running blocks
+// into stdout and stderr prefixing each line with a block name,
+// similarly to docker-compose.
+type prefixedOutput struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+	width  int
+}
+
+func newPrefixedOutput(stdout, stderr io.Writer, names []string) *prefixedOutput {
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	return &prefixedOutput{
+		stdout: stdout,
+		stderr: stderr,
+		width:  width,
+	}
+}
+
+// Writers returns writers for stdout and stderr of the named block.
+func (o *prefixedOutput) Writers(name string) (stdout, stderr *prefixWriter) {
+	prefix := []byte(fmt.Sprintf("%-*s | ", o.width, name))
+	return &prefixWriter{mu: &o.mu, w: o.stdout, prefix: prefix},
+		&prefixWriter{mu: &o.mu, w: o.stderr, prefix: prefix}
+}
+
+// prefixWriter writes complete lines preceded by a prefix.
+// Incomplete lines are buffered until a new line or Flush().
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:idx+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the buffered incomplete line, if any.
+func (w *prefixWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	err := w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+	return err
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	if _, err := w.w.Write(w.prefix); err != nil {
+		return err
+	}
+	_, err := w.w.Write(line)
+	return err
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/cli/v2/pkg/iostreams"
+	"github.com/cli/cli/v2/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func psCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "ps",
+		Short: "List commands started in background.",
+		Long:  "List commands started in background with \"runme start\" or the background attribute in the current project.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			procs, err := listBackgroundProcesses()
+			if err != nil {
+				return err
+			}
+
+			// TODO: this should be taken from cmd.
+			io := iostreams.System()
+			//lint:ignore SA1019 utils is deprecated but that's ok for now.
+			table := utils.NewTablePrinter(io)
+
+			table.AddField(strings.ToUpper("Name"), nil, nil)
+			table.AddField(strings.ToUpper("PID"), nil, nil)
+			table.AddField(strings.ToUpper("Status"), nil, nil)
+			table.AddField(strings.ToUpper("Started"), nil, nil)
+			table.AddField(strings.ToUpper("Log"), nil, nil)
+			table.EndRow()
+
+			for _, proc := range procs {
+				pid, running, err := proc.Running()
+				if err != nil {
+					return err
+				}
+
+				status := "exited"
+				if running {
+					status = "running"
+				}
+
+				startedAt, err := proc.StartedAt()
+				if err != nil {
+					return err
+				}
+
+				table.AddField(proc.name, nil, nil)
+				table.AddField(strconv.Itoa(pid), nil, nil)
+				table.AddField(status, nil, nil)
+				table.AddField(startedAt.Format(time.RFC3339), nil, nil)
+				table.AddField(proc.LogFile(), nil, nil)
+				table.EndRow()
+			}
+
+			return errors.Wrap(table.Render(), "failed to render")
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	return &cmd
+}
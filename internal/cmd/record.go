@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/asciicast"
+	xpty "github.com/stateful/runme/internal/pty"
+	"github.com/stateful/runme/internal/runner"
+)
+
+// recorder records output of executed blocks to a file
+// in the asciicast v2 format.
+type recorder struct {
+	*asciicast.Writer
+
+	f    *os.File
+	size runner.TerminalSize
+}
+
+// newRecorder creates a recording at path. The size of the recorded
+// terminal is the size of the current one or a default one.
+func newRecorder(path, command string) (*recorder, error) {
+	cols, rows := xpty.Size(os.Stdout)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create recording")
+	}
+
+	header := asciicast.Header{
+		Width:   cols,
+		Height:  rows,
+		Command: command,
+		Env:     map[string]string{},
+	}
+	for _, name := range []string{"SHELL", "TERM"} {
+		if value := os.Getenv(name); value != "" {
+			header.Env[name] = value
+		}
+	}
+
+	w, err := asciicast.NewWriter(f, header)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &recorder{
+		Writer: w,
+		f:      f,
+		size:   runner.TerminalSize{Cols: cols, Rows: rows},
+	}, nil
+}
+
+func (r *recorder) Close() error {
+	err := r.Writer.Close()
+	if cErr := r.f.Close(); err == nil && cErr != nil {
+		err = errors.Wrap(cErr, "failed to close recording")
+	}
+	return err
+}
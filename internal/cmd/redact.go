@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/stateful/runme/internal/redact"
+)
+
+// getRedactor returns a redactor masking values from the secrets files and
+// values of environment variables, and variables passed with --var or
+// prompted for, whose names match the patterns from the config. It returns
+// nil if masking is enabled neither by force nor in the config.
+func getRedactor(env []string, vars map[string]string, force bool) (*redact.Redactor, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if !force && (cfg.Secrets.Redact == nil || !*cfg.Secrets.Redact) {
+		return nil, nil
+	}
+
+	patterns := cfg.Secrets.Env
+	if len(patterns) == 0 {
+		patterns = redact.DefaultEnvPatterns
+	}
+
+	pairs := append([]string(nil), env...)
+	for name, value := range vars {
+		pairs = append(pairs, name+"="+value)
+	}
+
+	secrets := redact.FromEnv(pairs, patterns)
+
+	for _, name := range cfg.Secrets.Files {
+		values, err := redact.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, values...)
+	}
+
+	return redact.New(secrets...), nil
+}
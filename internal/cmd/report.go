@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/report"
+)
+
+// reportOutputSize is the number of trailing bytes
+// of stdout and stderr of each block kept in reports.
+const reportOutputSize = 1 << 20
+
+var reportWriters = map[string]func(io.Writer, *report.Report) error{
+	"junit": report.WriteJUnit,
+	"json":  report.WriteJSON,
+}
+
+type reportSpec struct {
+	format string
+	path   string
+}
+
+// parseReports parses values of --report in the FORMAT=PATH form.
+func parseReports(values []string) ([]reportSpec, error) {
+	var result []reportSpec
+	for _, value := range values {
+		format, path, ok := strings.Cut(value, "=")
+		if !ok || path == "" {
+			return nil, errors.Errorf("invalid report %q: expected FORMAT=PATH", value)
+		}
+		if _, ok := reportWriters[format]; !ok {
+			return nil, errors.Errorf("invalid report %q: unsupported format %q; supported formats: junit, json", value, format)
+		}
+		result = append(result, reportSpec{format: format, path: path})
+	}
+	return result, nil
+}
+
+func writeReports(specs []reportSpec, results []blockResult) error {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	r := newReport(results)
+
+	for _, spec := range specs {
+		f, err := os.Create(spec.path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s report", spec.format)
+		}
+
+		err = reportWriters[spec.format](f, r)
+		if cErr := f.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to write %s report to %s", spec.format, spec.path)
+		}
+	}
+
+	return nil
+}
+
+func newReport(results []blockResult) *report.Report {
+	r := &report.Report{
+		File:      fFileName,
+		Timestamp: time.Now(),
+	}
+
+	for _, result := range results {
+		tc := report.TestCase{
+			Name:     result.name,
+			File:     fFileName,
+			ExitCode: result.exitCode,
+			Duration: result.duration,
+			Stdout:   string(result.stdout),
+			Stderr:   string(result.stderr),
+		}
+
+		if result.err != nil {
+			tc.Message = result.err.Error()
+		}
+
+		switch result.status {
+		case blockStatusOK:
+			tc.Status = report.StatusPassed
+		case blockStatusFailed, blockStatusTimedOut:
+			tc.Status = report.StatusFailed
+		case blockStatusCanceled:
+			tc.Status = report.StatusError
+		case blockStatusSkipped:
+			tc.Status = report.StatusSkipped
+		}
+
+		r.Cases = append(r.Cases, tc)
+	}
+
+	return r
+}
@@ -74,6 +74,15 @@ func Root() *cobra.Command {
 	cmd.AddCommand(fmtCmd())
 	cmd.AddCommand(serverCmd())
 	cmd.AddCommand(shellCmd())
+	cmd.AddCommand(sessionCmd())
+	cmd.AddCommand(startCmd())
+	cmd.AddCommand(psCmd())
+	cmd.AddCommand(logsCmd())
+	cmd.AddCommand(stopCmd())
+	cmd.AddCommand(testCmd())
+	cmd.AddCommand(historyCmd())
+	cmd.AddCommand(playCmd())
+	cmd.AddCommand(trustCmd())
 	cmd.AddCommand(suggestCmd)
 	cmd.AddCommand(branchCmd)
 
@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/stateful/runme/internal/document"
 
@@ -15,117 +19,486 @@ import (
 	"github.com/rwtodd/Go.Sed/sed"
 	"github.com/spf13/cobra"
 	"github.com/stateful/runme/internal/runner"
+	"github.com/stateful/runme/internal/sandbox"
 )
 
 type runCmdOpts struct {
 	dryRun         bool
+	noDeps         bool
+	all            bool
+	section        string
+	failFast       bool
+	keepGoing      bool
+	parallel       bool
+	concurrency    int
+	session        string
+	varPairs       []string
+	vars           map[string]string
+	rememberVars   bool
+	foreground     bool
+	gracePeriod    time.Duration
+	reports        []string
+	tailSize       int
 	replaceScripts []string
+	record         string
+	recorder       *recorder
+	watch          string
+	sandbox        bool
+	sandboxNetwork bool
+	nonInteractive bool
+	yes            bool
+	redact         bool
+	detached       bool
+	varsFile       string
+	frontmatter    document.Frontmatter
 }
 
 func runCmd() *cobra.Command {
 	opts := runCmdOpts{}
 
 	cmd := cobra.Command{
-		Use:               "run",
-		Aliases:           []string{"exec"},
-		Short:             "Run a selected command.",
-		Long:              "Run a selected command identified based on its unique parsed name.",
-		Args:              cobra.ExactArgs(1),
+		Use:     "run",
+		Aliases: []string{"exec"},
+		Short:   "Run selected commands.",
+		Long: `Run commands identified based on their unique parsed names.
+
+Names can be glob patterns, for example, "db-*". Use --all to run all commands
+or --section to run commands placed under a heading. Commands are run
+sequentially in the given order after the commands they need, unless
+--parallel is used. In that case, each command starts as soon as
+the commands it needs succeed and its output is prefixed with its name.
+
+With --session, environment variables exported and the working directory
+changed by shell commands are restored before running next commands,
+also in subsequent invocations, until the session is cleared
+with "runme session clear".
+
+Placeholders like <your-token>, exports without values like "export TOKEN=",
+and references to unset variables like $PROJECT_ID in shell commands are
+prompted for when running in a terminal. Values can also be passed with --var.
+
+Commands with the background attribute are started in background like with
+"runme start", unless --foreground is used.
+
+Each command runs in its own process group. SIGINT and SIGTERM are forwarded
+to the whole group, which is killed if it does not exit within --grace-period.
+
+With --record, commands run in a pseudo-terminal and their output is recorded
+with timing in the asciicast v2 format. Use "runme play" to replay it.
+
+With --watch, commands are run again whenever the markdown file or files
+under --chdir matching the given glob pattern change. Changes of the markdown
+file cancel a run in progress, while changes of other files made during
+a run, possibly by the commands themselves, are ignored. Patterns without
+slashes match files in any directory and "**" matches any number
+of directories, for example, "src/**/*.proto".
+
+With --sandbox, commands run isolated using Linux namespaces. The system
+directories are read-only, changes to the project directory are discarded
+when the command exits, and the home directory and /tmp are empty.
+The network is disabled unless --sandbox-network is used. Commands get
+only PATH, HOME, TERM, and LANG of runme's environment, in addition to
+variables set by the block, the front matter, the session, and --var.
+
+With --redact, secrets are masked in the output. These are values of
+environment variables with names like *_TOKEN or *_PASSWORD, values from
+secrets files, and tokens like AWS access keys, GitHub tokens, and JWTs.
+It can be enabled and configured in the "secrets" section of .runme.yaml.
+
+Commands from remote files and commands which changed since they were last
+approved are shown and must be confirmed before running. With
+--non-interactive, runme fails instead. See "runme trust" for details.
+
+Commands with the confirm=true attribute and commands which look destructive,
+like "rm -rf" or "kubectl delete", must be confirmed too, unless --yes is used.
+Additional patterns can be defined, and built-in ones disabled, in the "danger"
+section of .runme.yaml.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !opts.all && opts.section == "" {
+				return errors.New("requires at least one command name, --all, or --section")
+			}
+			return nil
+		},
 		ValidArgsFunction: validCmdNames,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			blocks, err := getCodeBlocks()
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if !opts.failFast {
+				opts.keepGoing = true
+			}
+
+			reports, err := parseReports(opts.reports)
 			if err != nil {
 				return err
 			}
+			if len(reports) > 0 {
+				opts.tailSize = reportOutputSize
+			}
 
-			block, err := lookupCodeBlock(blocks, args[0])
+			opts.vars, err = parseVars(opts.varPairs)
 			if err != nil {
 				return err
 			}
 
-			return runBlock(cmd, block, &opts)
+			if opts.varsFile != "" {
+				vars, err := readVarsFile(opts.varsFile)
+				if err != nil {
+					return err
+				}
+				for key, value := range opts.vars {
+					vars[key] = value
+				}
+				opts.vars = vars
+			}
+
+			if opts.rememberVars {
+				remembered, err := loadRememberedVars()
+				if err != nil {
+					return err
+				}
+				for key, value := range opts.vars {
+					remembered[key] = value
+				}
+				opts.vars = remembered
+
+				if len(opts.varPairs) > 0 {
+					if err := saveRememberedVars(opts.vars); err != nil {
+						return err
+					}
+				}
+			}
+
+			if opts.record != "" && !opts.dryRun {
+				opts.recorder, err = newRecorder(opts.record, "runme run "+strings.Join(args, " "))
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if cErr := opts.recorder.Close(); cErr != nil && err == nil {
+						err = cErr
+					}
+				}()
+			}
+
+			ctx, cancel := ctxWithSigCancel(cmd.Context())
+			defer cancel()
+
+			// Blocks are read on every run as the markdown file
+			// can change in between with --watch.
+			run := func(ctx context.Context) error {
+				blocks, fmatter, err := getDocument()
+				if err != nil {
+					return err
+				}
+				opts.frontmatter = fmatter
+
+				selected, err := selectCodeBlocks(blocks, args, opts.all, opts.section)
+				if err != nil {
+					return err
+				}
+
+				plan, err := resolvePlan(blocks, selected, opts.noDeps)
+				if err != nil {
+					return err
+				}
+
+				if opts.dryRun {
+					printPlan(cmd.ErrOrStderr(), plan)
+				} else {
+					if err := checkTrust(cmd, plan, &opts); err != nil {
+						return err
+					}
+					if err := confirmBlocks(cmd, plan, &opts); err != nil {
+						return err
+					}
+				}
+
+				var results []blockResult
+				if opts.parallel && !opts.dryRun {
+					results = runPlanParallel(ctx, cmd, plan, &opts)
+				} else {
+					results = runPlan(ctx, cmd, plan, &opts)
+				}
+
+				if len(results) > 1 && !opts.dryRun {
+					_, _ = fmt.Fprintln(cmd.OutOrStdout())
+					if err := printSummary(results); err != nil {
+						return err
+					}
+				}
+
+				if !opts.dryRun {
+					if err := writeReports(reports, results); err != nil {
+						return err
+					}
+				}
+
+				return planError(results)
+			}
+
+			if cmd.Flags().Changed("watch") {
+				return watchAndRun(ctx, cmd, opts.watch, run)
+			}
+
+			return run(ctx)
 		},
 	}
 
 	setDefaultFlags(&cmd)
 
 	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Print the final command without executing.")
+	cmd.Flags().BoolVar(&opts.noDeps, "no-deps", false, "Do not run commands listed in the \"needs\" attribute.")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Run all commands.")
+	cmd.Flags().StringVar(&opts.section, "section", "", "Run commands placed under a heading.")
+	cmd.Flags().BoolVar(&opts.failFast, "fail-fast", true, "Stop on the first failed command.")
+	cmd.Flags().BoolVar(&opts.keepGoing, "keep-going", false, "Run remaining commands after a command fails.")
+	cmd.Flags().BoolVar(&opts.parallel, "parallel", false, "Run commands concurrently.")
+	cmd.Flags().IntVar(&opts.concurrency, "concurrency", 0, "Maximum number of commands run concurrently with --parallel. Zero means no limit.")
+	cmd.Flags().StringVar(&opts.session, "session", "", "Persist the environment and working directory of shell commands in a named session.")
+	cmd.Flags().StringArrayVar(&opts.reports, "report", nil, "Write a report of executed commands as FORMAT=PATH. Supported formats are junit and json.")
+	cmd.Flags().DurationVar(&opts.gracePeriod, "grace-period", runner.DefaultGracePeriod, "Time given to commands to exit after an interrupt or timeout before they are killed.")
+	cmd.Flags().BoolVar(&opts.foreground, "foreground", false, "Run commands with the background attribute in foreground.")
+	cmd.Flags().StringArrayVar(&opts.varPairs, "var", nil, "Set a value of a placeholder or variable as KEY=VALUE.")
+	cmd.Flags().BoolVar(&opts.rememberVars, "remember-vars", false, "Remember prompted and passed values for this project and use them in next runs.")
 	cmd.Flags().StringArrayVarP(&opts.replaceScripts, "replace", "r", nil, "Replace instructions using sed.")
+	cmd.Flags().StringVar(&opts.record, "record", "", "Record output of commands run in a pseudo-terminal to a file in the asciicast v2 format.")
+	cmd.Flags().StringVar(&opts.watch, "watch", "", "Run commands again when the markdown file or files matching a glob pattern change.")
+	cmd.Flags().Lookup("watch").NoOptDefVal = "*.md"
+	cmd.Flags().BoolVar(&opts.sandbox, "sandbox", false, "Run commands isolated from the system using Linux namespaces.")
+	cmd.Flags().BoolVar(&opts.sandboxNetwork, "sandbox-network", false, "Allow access to the network with --sandbox.")
+	cmd.Flags().BoolVar(&opts.redact, "redact", false, "Mask secrets, like tokens and passwords, in the output.")
+	cmd.Flags().BoolVar(&opts.nonInteractive, "non-interactive", false, "Fail instead of asking to approve new or changed commands.")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "Run commands requiring confirmation without asking.")
+	// Set by "runme start" which stops the detached process group as a whole.
+	cmd.Flags().BoolVar(&opts.detached, "detached", false, "Run commands in the process group of runme.")
+	_ = cmd.Flags().MarkHidden("detached")
+	// Set by "runme start" to pass values of variables without showing them in ps.
+	cmd.Flags().StringVar(&opts.varsFile, "vars-file", "", "Read values of variables from a JSON file and remove it.")
+	_ = cmd.Flags().MarkHidden("vars-file")
+
+	cmd.MarkFlagsMutuallyExclusive("fail-fast", "keep-going")
+	cmd.MarkFlagsMutuallyExclusive("parallel", "session")
+	cmd.MarkFlagsMutuallyExclusive("parallel", "record")
+	cmd.MarkFlagsMutuallyExclusive("watch", "record")
 
 	return &cmd
 }
 
-func runBlock(cmd *cobra.Command, block *document.CodeBlock, opts *runCmdOpts) error {
+func printPlan(w io.Writer, plan document.CodeBlocks) {
+	_, _ = fmt.Fprintf(w, "// plan\n")
+	for idx, block := range plan {
+		_, _ = fmt.Fprintf(w, "// %d. %s\n", idx+1, block.Name())
+	}
+	_, _ = fmt.Fprintf(w, "\n")
+}
+
+// blockStreams are standard streams connected to an executed block.
+type blockStreams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func cmdStreams(cmd *cobra.Command) blockStreams {
+	return blockStreams{
+		stdin:  cmd.InOrStdin(),
+		stdout: cmd.OutOrStdout(),
+		stderr: cmd.ErrOrStderr(),
+	}
+}
+
+// runBlock runs the block and returns the result of its last attempt.
+// The result is nil if the block was not executed by runme directly,
+// for example, when it was started in background or in dry-run mode.
+func runBlock(ctx context.Context, block *document.CodeBlock, opts *runCmdOpts, streams blockStreams) (*runner.Result, error) {
 	if opts == nil {
 		opts = &runCmdOpts{}
 	}
 
 	if err := replace(opts.replaceScripts, block.Lines()); err != nil {
-		return err
+		return nil, err
 	}
 
 	if id, ok := shellID(); ok && runner.IsShell(block) {
-		return executeInShell(id, block)
+		return nil, executeInShell(id, block)
+	}
+
+	if isBackground(block) && !opts.foreground && !opts.dryRun {
+		return nil, startInBackground(block, opts, streams)
 	}
 
-	executable, err := newExecutable(cmd, block)
+	policy, err := getRunPolicy(block)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	fmatter := opts.frontmatter
+
+	env, session, err := loadBlockEnv(block, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := resolveVariables(block, env, opts, streams)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx, cancel := ctxWithSigCancel(cmd.Context())
-	defer cancel()
+	executable, err := newExecutable(block, fmatter, runner.MergeEnv(env, refs...), session, opts, streams)
+	if err != nil {
+		return nil, err
+	}
 
 	if opts.dryRun {
-		executable.DryRun(ctx, cmd.ErrOrStderr())
-		return nil
+		executable.DryRun(ctx, streams.stderr)
+		return nil, nil
+	}
+
+	// The directory is taken before running as the session can change it.
+	dir, _ := filepath.Abs(blockDir(block, fmatter, session))
+	start := time.Now()
+
+	result, err := policy.run(ctx, block.Name(), streams.stderr, executable.Run)
+
+	recordHistory(block, dir, opts, exitCode(result, err), start)
+
+	if session != nil {
+		if sErr := saveSession(opts.session, session); sErr != nil && err == nil {
+			err = sErr
+		}
 	}
 
-	return errors.WithStack(executable.Run(ctx))
+	return result, errors.WithStack(err)
 }
 
-func newExecutable(cmd *cobra.Command, block *document.CodeBlock) (runner.Executable, error) {
+// loadBlockEnv loads the session, if any, and returns
+// the environment the block is run with.
+func loadBlockEnv(block *document.CodeBlock, opts *runCmdOpts) ([]string, *runner.Session, error) {
+	var (
+		session *runner.Session
+		err     error
+	)
+	if opts.session != "" {
+		session, err = loadSession(opts.session)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Sandboxed commands don't see secrets in runme's environment.
+	baseEnv := os.Environ()
+	if opts.sandbox {
+		baseEnv = sandbox.Environ()
+	}
+
+	env, err := blockEnv(baseEnv, block, opts.frontmatter, session)
+	return env, session, err
+}
+
+// startInBackground starts the block detached. Commands it needs
+// are expected to have been run already.
+func startInBackground(block *document.CodeBlock, opts *runCmdOpts, streams blockStreams) error {
+	if err := resolveBackgroundVariables(document.CodeBlocks{block}, opts, streams); err != nil {
+		return err
+	}
+
+	proc, err := getBackgroundProcess(block.Name())
+	if err != nil {
+		return err
+	}
+
+	bgOpts := *opts
+	bgOpts.noDeps = true
+
+	pid, err := proc.Start(&bgOpts)
+	if err != nil {
+		return err
+	}
+
+	printStarted(streams.stderr, block.Name(), pid)
+
+	return nil
+}
+
+// newExecutable creates an executable for the block run with env.
+// If session is not nil, its working directory is restored and,
+// in case of shell blocks, it's updated after the execution.
+func newExecutable(
+	block *document.CodeBlock,
+	fmatter document.Frontmatter,
+	env []string,
+	session *runner.Session,
+	opts *runCmdOpts,
+	streams blockStreams,
+) (runner.Executable, error) {
 	base := &runner.Base{
-		Dir:    fChdir,
-		Stdin:  cmd.InOrStdin(),
-		Stdout: cmd.OutOrStdout(),
-		Stderr: cmd.ErrOrStderr(),
-		Name:   block.Name(),
-	}
-
-	switch block.Language() {
-	case "bash", "bat", "sh", "shell", "zsh":
-		return &runner.Shell{
-			Cmds: block.Lines(),
-			Base: base,
-		}, nil
-	case "sh-raw":
-		return &runner.ShellRaw{
-			Cmds: block.Lines(),
-			Base: base,
-		}, nil
-	case "go":
-		return &runner.Go{
-			Source: string(block.Content()),
-			Base:   base,
-		}, nil
-	default:
-		return nil, errors.Errorf("unknown executable: %q", block.Language())
+		Dir:         blockDir(block, fmatter, session),
+		Env:         env,
+		Stdin:       streams.stdin,
+		Stdout:      streams.stdout,
+		Stderr:      streams.stderr,
+		Name:        block.Name(),
+		Session:     session,
+		GracePeriod: opts.gracePeriod,
+		TailSize:    opts.tailSize,
+		// Detached runme leads the process group stopped by "runme stop".
+		InheritProcessGroup: opts.detached,
+	}
+
+	if opts.recorder != nil {
+		base.Terminal = &opts.recorder.size
+		base.Stdout = io.MultiWriter(streams.stdout, opts.recorder)
+	}
+
+	if opts.sandbox {
+		projectDir, err := filepath.Abs(fChdir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		base.Sandbox = &sandbox.Options{ProjectDir: projectDir, Network: opts.sandboxNetwork}
+	}
+
+	redactor, err := getRedactor(env, opts.vars, opts.redact)
+	if err != nil {
+		return nil, err
 	}
+	if redactor != nil && !opts.dryRun {
+		base.Redactor = redactor
+	}
+
+	registry, err := getRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.New(base, block, interpreter(block, fmatter))
+}
+
+// interpreter returns an interpreter requested for the block
+// either by its "interpreter" attribute or in the front matter.
+func interpreter(block *document.CodeBlock, fmatter document.Frontmatter) string {
+	if name := block.Attributes()["interpreter"]; name != "" {
+		return name
+	}
+	return fmatter.Interpreters[block.Language()]
 }
 
+// ctxWithSigCancel returns a context canceled on SIGINT or SIGTERM.
+// The received signal is forwarded to running commands.
 func ctxWithSigCancel(ctx context.Context) (context.Context, context.CancelFunc) {
-	ctx, cancel := context.WithCancel(ctx)
+	ctx, cancel := runner.WithCancelSignal(ctx)
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
-		<-sigs
-		cancel()
+		select {
+		case sig := <-sigs:
+			cancel(sig)
+		case <-ctx.Done():
+		}
+		signal.Stop(sigs)
 	}()
 
-	return ctx, cancel
+	return ctx, func() { cancel(nil) }
 }
 
 func replace(scripts []string, lines []string) error {
@@ -145,6 +518,8 @@ func replace(scripts []string, lines []string) error {
 			if err != nil {
 				return errors.Wrapf(err, "failed to run sed script %q on line %q", script, line)
 			}
+			// sed terminates every output line with a newline.
+			lines[idx] = strings.TrimSuffix(lines[idx], "\n")
 		}
 	}
 
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cli/cli/v2/pkg/iostreams"
+	"github.com/cli/cli/v2/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/runner"
+)
+
+// selectCodeBlocks returns blocks matching names, which can be glob patterns,
+// or belonging to the section. If all is true, all blocks are returned.
+func selectCodeBlocks(blocks document.CodeBlocks, names []string, all bool, section string) (document.CodeBlocks, error) {
+	if all {
+		return blocks, nil
+	}
+
+	var result document.CodeBlocks
+
+	for _, name := range names {
+		if !strings.ContainsAny(name, "*?[") {
+			block, err := lookupCodeBlock(blocks, name)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, block)
+			continue
+		}
+
+		matched := false
+		for _, block := range blocks {
+			ok, err := path.Match(name, block.Name())
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid pattern %q", name)
+			}
+			if ok {
+				matched = true
+				result = append(result, block)
+			}
+		}
+		if !matched {
+			return nil, errors.Errorf("no command matches %q; known command names: %s", name, blocks.Names())
+		}
+	}
+
+	if section != "" {
+		matched := false
+		for _, block := range blocks {
+			if block.InSection(section) {
+				matched = true
+				result = append(result, block)
+			}
+		}
+		if !matched {
+			return nil, errors.Errorf("no commands in section %q", section)
+		}
+	}
+
+	return result, nil
+}
+
+// resolvePlan returns the selected blocks preceded by their dependencies,
+// unless noDeps is true. Each block occurs in the plan only once.
+func resolvePlan(blocks, selected document.CodeBlocks, noDeps bool) (document.CodeBlocks, error) {
+	var (
+		plan document.CodeBlocks
+		seen = make(map[string]bool)
+	)
+
+	for _, block := range selected {
+		deps := document.CodeBlocks{block}
+		if !noDeps {
+			var err error
+			deps, err = blocks.ResolveDependencies(block.Name())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, dep := range deps {
+			if !seen[dep.Name()] {
+				seen[dep.Name()] = true
+				plan = append(plan, dep)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+type blockStatus string
+
+const (
+	blockStatusOK       blockStatus = "ok"
+	blockStatusFailed   blockStatus = "failed"
+	blockStatusTimedOut blockStatus = "timeout"
+	blockStatusCanceled blockStatus = "canceled"
+	blockStatusSkipped  blockStatus = "skipped"
+)
+
+func (s blockStatus) failed() bool {
+	return s == blockStatusFailed || s == blockStatusTimedOut
+}
+
+type blockResult struct {
+	name     string
+	status   blockStatus
+	exitCode int
+	duration time.Duration
+	err      error
+	// stdout and stderr are tails of the output kept
+	// if runCmdOpts.tailSize is positive.
+	stdout []byte
+	stderr []byte
+}
+
+// runPlan runs blocks sequentially. Unless keepGoing is true,
+// it stops on the first failure and marks remaining blocks as skipped.
+func runPlan(ctx context.Context, cmd *cobra.Command, plan document.CodeBlocks, opts *runCmdOpts) []blockResult {
+	results := make([]blockResult, 0, len(plan))
+	failed := false
+
+	for _, block := range plan {
+		result := blockResult{
+			name:     block.Name(),
+			status:   blockStatusSkipped,
+			exitCode: -1,
+		}
+
+		if (!failed || opts.keepGoing) && ctx.Err() == nil {
+			result = runPlannedBlock(ctx, block, opts, cmdStreams(cmd))
+			failed = failed || result.status.failed()
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// runPlanParallel runs blocks concurrently. A block starts when all blocks
+// it needs succeed and the concurrency limit allows it. Unless keepGoing
+// is true, the first failure cancels all running blocks.
+func runPlanParallel(ctx context.Context, cmd *cobra.Command, plan document.CodeBlocks, opts *runCmdOpts) []blockResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var sem chan struct{}
+	if opts.concurrency > 0 {
+		sem = make(chan struct{}, opts.concurrency)
+	}
+
+	var (
+		results = make([]blockResult, len(plan))
+		done    = make(map[string]chan struct{}, len(plan))
+		indexes = make(map[string]int, len(plan))
+		output  = newPrefixedOutput(cmd.OutOrStdout(), cmd.ErrOrStderr(), plan.Names())
+		wg      sync.WaitGroup
+	)
+
+	for idx, block := range plan {
+		done[block.Name()] = make(chan struct{})
+		indexes[block.Name()] = idx
+	}
+
+	for idx, block := range plan {
+		wg.Add(1)
+
+		go func(idx int, block *document.CodeBlock) {
+			defer wg.Done()
+			defer close(done[block.Name()])
+
+			results[idx] = blockResult{
+				name:     block.Name(),
+				status:   blockStatusSkipped,
+				exitCode: -1,
+			}
+
+			for _, name := range block.Needs() {
+				depDone, ok := done[name]
+				if !ok {
+					// The dependency is not a part of the plan, for example, due to --no-deps.
+					continue
+				}
+				<-depDone
+				if results[indexes[name]].status != blockStatusOK {
+					return
+				}
+			}
+
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			stdout, stderr := output.Writers(block.Name())
+			defer func() { _ = stdout.Flush(); _ = stderr.Flush() }()
+
+			result := runPlannedBlock(ctx, block, opts, blockStreams{stdout: stdout, stderr: stderr})
+			if result.status == blockStatusFailed && ctx.Err() != nil {
+				result.status = blockStatusCanceled
+			}
+			results[idx] = result
+
+			if result.status.failed() && !opts.keepGoing {
+				cancel()
+			}
+		}(idx, block)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func runPlannedBlock(ctx context.Context, block *document.CodeBlock, opts *runCmdOpts, streams blockStreams) blockResult {
+	start := time.Now()
+	runResult, err := runBlock(ctx, block, opts, streams)
+
+	result := blockResult{
+		name:     block.Name(),
+		status:   blockStatusOK,
+		exitCode: exitCode(runResult, err),
+		duration: time.Since(start),
+		err:      err,
+	}
+	if runResult != nil {
+		result.stdout = runResult.Stdout
+		result.stderr = runResult.Stderr
+	}
+	var timeoutErr *timeoutError
+	switch {
+	case errors.As(err, &timeoutErr):
+		result.status = blockStatusTimedOut
+	case err != nil:
+		result.status = blockStatusFailed
+	}
+	return result
+}
+
+// planError returns an error summarizing failures in results.
+// If no block failed on its own, an error of a canceled block is returned.
+func planError(results []blockResult) error {
+	var failed, canceled []blockResult
+	for _, r := range results {
+		switch r.status {
+		case blockStatusFailed, blockStatusTimedOut:
+			failed = append(failed, r)
+		case blockStatusCanceled:
+			canceled = append(canceled, r)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		if len(canceled) > 0 {
+			return canceled[0].err
+		}
+		return nil
+	case 1:
+		return failed[0].err
+	default:
+		return errors.Errorf("%d of %d commands failed", len(failed), len(results))
+	}
+}
+
+func printSummary(results []blockResult) error {
+	// TODO: this should be taken from cmd.
+	io := iostreams.System()
+	//lint:ignore SA1019 utils is deprecated but that's ok for now.
+	table := utils.NewTablePrinter(io)
+
+	// table header
+	table.AddField(strings.ToUpper("Name"), nil, nil)
+	table.AddField(strings.ToUpper("Status"), nil, nil)
+	table.AddField(strings.ToUpper("Exit Code"), nil, nil)
+	table.AddField(strings.ToUpper("Duration"), nil, nil)
+	table.EndRow()
+
+	for _, r := range results {
+		code := "-"
+		if r.exitCode >= 0 {
+			code = fmt.Sprintf("%d", r.exitCode)
+		}
+
+		duration := "-"
+		if r.status != blockStatusSkipped {
+			duration = r.duration.Round(time.Millisecond).String()
+		}
+
+		table.AddField(r.name, nil, nil)
+		table.AddField(string(r.status), nil, nil)
+		table.AddField(code, nil, nil)
+		table.AddField(duration, nil, nil)
+		table.EndRow()
+	}
+
+	return errors.Wrap(table.Render(), "failed to render")
+}
+
+// exitCode returns the exit code of a block which ran with the result
+// and err or -1 if err is not caused by a command exiting. Timeouts are
+// reported with their own exit code.
+func exitCode(result *runner.Result, err error) int {
+	var timeoutErr *timeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutExitCode
+	}
+	if result != nil {
+		return result.ExitCode
+	}
+	if err == nil {
+		return 0
+	}
+	var signalErr *runner.SignalError
+	if errors.As(err, &signalErr) {
+		return signalErr.ExitCode()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/runner"
+)
+
+// timeoutExitCode is the exit code used when a command times out.
+// It's the same as the one used by timeout(1).
+const timeoutExitCode = 124
+
+const defaultRetryDelay = time.Second
+
+type runnerFunc func(context.Context) (*runner.Result, error)
+
+// timeoutError is returned when a command does not finish
+// within the time set by its "timeout" attribute.
+type timeoutError struct {
+	name    string
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("command %q timed out after %s", e.name, e.timeout)
+}
+
+// runPolicy describes how a block is run based on its attributes:
+//
+//	timeout=5m        stops the command after the given duration
+//	retries=3         retries a failed command up to the given number of times
+//	retry-delay=2s    delays the first retry; subsequent delays are doubled
+type runPolicy struct {
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+}
+
+func getRunPolicy(block *document.CodeBlock) (p runPolicy, err error) {
+	attrs := block.Attributes()
+
+	if v := attrs["timeout"]; v != "" {
+		p.timeout, err = time.ParseDuration(v)
+		if err != nil || p.timeout <= 0 {
+			return p, errors.Errorf("invalid timeout %q of command %q: expected a positive duration like 30s or 5m", v, block.Name())
+		}
+	}
+
+	if v := attrs["retries"]; v != "" {
+		p.retries, err = strconv.Atoi(v)
+		if err != nil || p.retries < 0 {
+			return p, errors.Errorf("invalid retries %q of command %q: expected a non-negative integer", v, block.Name())
+		}
+	}
+
+	p.retryDelay = defaultRetryDelay
+	if v := attrs["retry-delay"]; v != "" {
+		p.retryDelay, err = time.ParseDuration(v)
+		if err != nil || p.retryDelay < 0 {
+			return p, errors.Errorf("invalid retry-delay %q of command %q: expected a duration like 500ms or 2s", v, block.Name())
+		}
+	}
+
+	return p, nil
+}
+
+// run calls fn until it succeeds or the number of retries is exhausted.
+// Retries are reported to w. The result of the last attempt is returned.
+func (p runPolicy) run(ctx context.Context, name string, w io.Writer, fn runnerFunc) (*runner.Result, error) {
+	delay := p.retryDelay
+
+	for attempt := 1; ; attempt++ {
+		result, err := p.runOnce(ctx, name, fn)
+		if err == nil || attempt > p.retries || ctx.Err() != nil {
+			return result, err
+		}
+
+		_, _ = fmt.Fprintf(w, "runme: %s; retrying in %s (%d/%d)\n", err, delay, attempt, p.retries)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return result, err
+		}
+
+		delay *= 2
+	}
+}
+
+func (p runPolicy) runOnce(ctx context.Context, name string, fn runnerFunc) (*runner.Result, error) {
+	if p.timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	result, err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return result, &timeoutError{name: name, timeout: p.timeout}
+	}
+	return result, err
+}
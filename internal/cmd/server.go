@@ -26,8 +26,9 @@ func serverCmd() *cobra.Command {
 	const defaultSocketAddr = "/var/run/runme.sock"
 
 	var (
-		addr string
-		web  bool
+		addr         string
+		web          bool
+		redactOutput bool
 	)
 
 	cmd := cobra.Command{
@@ -47,6 +48,11 @@ The kernel is used to run long running processes like shells and interacting wit
 			}
 			defer logger.Sync()
 
+			redactor, err := getRedactor(os.Environ(), nil, redactOutput)
+			if err != nil {
+				return err
+			}
+
 			// When web is true, the server command exposes a gRPC-compatible HTTP API.
 			// Read more on https://connect.build/docs/introduction.
 			if web {
@@ -56,7 +62,7 @@ The kernel is used to run long running processes like shells and interacting wit
 
 				mux := http.NewServeMux()
 				compress1KB := connect.WithCompressMinBytes(1024)
-				mux.Handle(kernelv1connect.NewKernelServiceHandler(kernel.NewKernelServiceHandler(logger)))
+				mux.Handle(kernelv1connect.NewKernelServiceHandler(kernel.NewKernelServiceHandler(logger, redactor)))
 				mux.Handle(grpchealth.NewHandler(
 					grpchealth.NewStaticChecker(),
 					compress1KB,
@@ -101,7 +107,7 @@ The kernel is used to run long running processes like shells and interacting wit
 			var opts []grpc.ServerOption
 			server := grpc.NewServer(opts...)
 			parserv1.RegisterParserServiceServer(server, editorservice.NewParserServiceServer(logger))
-			kernelv1.RegisterKernelServiceServer(server, kernel.NewKernelServiceServer(logger))
+			kernelv1.RegisterKernelServiceServer(server, kernel.NewKernelServiceServer(logger, redactor))
 			return server.Serve(lis)
 		},
 	}
@@ -110,6 +116,7 @@ The kernel is used to run long running processes like shells and interacting wit
 
 	cmd.Flags().StringVarP(&addr, "address", "a", defaultSocketAddr, "A path to create a socket.")
 	cmd.Flags().BoolVar(&web, "web", false, "Use Connect Protocol (https://connect.build/).")
+	cmd.Flags().BoolVar(&redactOutput, "redact", false, "Mask secrets, like tokens and passwords, in the output of sessions.")
 
 	return &cmd
 }
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/runner"
+)
+
+var sessionNameRe = regexp.MustCompile(`^[\w.-]+$`)
+
+func getSessionsDir() string {
+	return filepath.Join(getDefaultConfigHome(), "runme", "sessions")
+}
+
+func sessionPath(name string) (string, error) {
+	if !sessionNameRe.MatchString(name) {
+		return "", errors.Errorf("invalid session name %q: only letters, digits, '.', '-', and '_' are allowed", name)
+	}
+	return filepath.Join(getSessionsDir(), name+".json"), nil
+}
+
+// loadSession reads the named session from disk.
+// A session which has not been saved yet is empty.
+func loadSession(name string) (*runner.Session, error) {
+	path, err := sessionPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &runner.Session{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read session %q", name)
+	}
+
+	var session runner.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse session %q", name)
+	}
+	return &session, nil
+}
+
+func saveSession(name string, session *runner.Session) error {
+	path, err := sessionPath(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create sessions dir")
+	}
+
+	// The environment might contain secrets hence
+	// the file is readable only by the owner.
+	return errors.Wrapf(os.WriteFile(path, data, 0o600), "failed to write session %q", name)
+}
+
+func sessionCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "session",
+		Short: "Manage sessions.",
+		Long: `Manage sessions created with "runme run --session NAME".
+
+A session keeps the environment variables and the working directory left
+by shell commands and restores them before running the next command.`,
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.AddCommand(sessionListCmd())
+	cmd.AddCommand(sessionClearCmd())
+
+	return &cmd
+}
+
+func sessionListCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "list",
+		Short: "List saved sessions.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := os.ReadDir(getSessionsDir())
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return errors.Wrap(err, "failed to read sessions dir")
+			}
+
+			var names []string
+			for _, entry := range entries {
+				if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+					names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+				}
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), name)
+			}
+
+			return nil
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	return &cmd
+}
+
+func sessionClearCmd() *cobra.Command {
+	var all bool
+
+	cmd := cobra.Command{
+		Use:   "clear",
+		Short: "Clear sessions.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !all {
+				return errors.New("requires at least one session name or --all")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return errors.Wrap(os.RemoveAll(getSessionsDir()), "failed to remove sessions")
+			}
+
+			for _, name := range args {
+				path, err := sessionPath(name)
+				if err != nil {
+					return err
+				}
+				if err := os.Remove(path); err != nil {
+					if errors.Is(err, os.ErrNotExist) {
+						return errors.Errorf("session %q not found", name)
+					}
+					return errors.Wrapf(err, "failed to remove session %q", name)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVar(&all, "all", false, "Clear all sessions.")
+
+	return &cmd
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/document"
+)
+
+func startCmd() *cobra.Command {
+	opts := runCmdOpts{}
+
+	cmd := cobra.Command{
+		Use:   "start",
+		Short: "Start commands in background.",
+		Long: `Start commands in background, detached from the terminal.
+
+Their output is written to log files which can be displayed with "runme logs".
+Use "runme ps" to list them and "runme stop" to stop them.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: validCmdNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			blocks, fmatter, err := getDocument()
+			if err != nil {
+				return err
+			}
+			opts.frontmatter = fmatter
+
+			var selected document.CodeBlocks
+			for _, name := range args {
+				block, err := lookupCodeBlock(blocks, name)
+				if err != nil {
+					return err
+				}
+				selected = append(selected, block)
+			}
+
+			plan, err := resolvePlan(blocks, selected, opts.noDeps)
+			if err != nil {
+				return err
+			}
+
+			if err := checkTrust(cmd, plan, &opts); err != nil {
+				return err
+			}
+
+			if err := confirmBlocks(cmd, plan, &opts); err != nil {
+				return err
+			}
+
+			opts.vars, err = parseVars(opts.varPairs)
+			if err != nil {
+				return err
+			}
+
+			for _, block := range plan {
+				if err := replace(opts.replaceScripts, block.Lines()); err != nil {
+					return err
+				}
+			}
+
+			if err := resolveBackgroundVariables(plan, &opts, cmdStreams(cmd)); err != nil {
+				return err
+			}
+
+			for _, name := range args {
+				proc, err := getBackgroundProcess(name)
+				if err != nil {
+					return err
+				}
+
+				pid, err := proc.Start(&opts)
+				if err != nil {
+					return err
+				}
+
+				printStarted(cmd.ErrOrStderr(), name, pid)
+			}
+
+			return nil
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVar(&opts.noDeps, "no-deps", false, "Do not run commands listed in the \"needs\" attribute.")
+	cmd.Flags().StringVar(&opts.session, "session", "", "Run commands in a named session.")
+	cmd.Flags().StringArrayVar(&opts.varPairs, "var", nil, "Set a value of a placeholder or variable as KEY=VALUE.")
+	cmd.Flags().StringArrayVarP(&opts.replaceScripts, "replace", "r", nil, "Replace instructions using sed.")
+	cmd.Flags().BoolVar(&opts.nonInteractive, "non-interactive", false, "Fail instead of asking to approve new or changed commands.")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "Run commands requiring confirmation without asking.")
+	cmd.Flags().BoolVar(&opts.redact, "redact", false, "Mask secrets, like tokens and passwords, in the output.")
+
+	return &cmd
+}
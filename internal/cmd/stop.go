@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func stopCmd() *cobra.Command {
+	var (
+		all     bool
+		timeout time.Duration
+	)
+
+	cmd := cobra.Command{
+		Use:   "stop",
+		Short: "Stop commands started in background.",
+		Long: `Stop commands started in background by terminating their process groups.
+
+Commands which do not exit within --timeout are killed.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !all {
+				return errors.New("requires at least one command name or --all")
+			}
+			return nil
+		},
+		ValidArgsFunction: validCmdNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var procs []*backgroundProcess
+
+			if all {
+				var err error
+				procs, err = listBackgroundProcesses()
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, name := range args {
+				proc, err := getBackgroundProcess(name)
+				if err != nil {
+					return err
+				}
+				if pid, err := proc.Pid(); err != nil {
+					return err
+				} else if pid == 0 {
+					return errors.Errorf("command %q was not started in background", name)
+				}
+				procs = append(procs, proc)
+			}
+
+			for _, proc := range procs {
+				stopped, err := proc.Stop(cmd.Context(), timeout)
+				if err != nil {
+					return err
+				}
+				if stopped {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "runme: stopped %q\n", proc.name)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVar(&all, "all", false, "Stop all commands started in background.")
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultStopTimeout, "Time to wait for a command to exit before killing it.")
+
+	return &cmd
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/doctest"
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/renderer/cmark"
+	"github.com/stateful/runme/internal/runner"
+)
+
+func testCmd() *cobra.Command {
+	var (
+		update bool
+		opts   = runCmdOpts{}
+	)
+
+	cmd := cobra.Command{
+		Use:   "test",
+		Short: "Run commands and check their results.",
+		Long: `Run commands and compare their exit codes and output with expectations.
+
+Expectations are written down in the markdown file. The "expect-exit" attribute
+sets the expected exit code, which is 0 by default, and the "expect-output"
+attribute sets the expected output. Longer outputs are written in an output
+block following the command, for example:
+
+    ` + "```" + `sh { name=greet }
+    echo hello
+    ` + "```" + `
+
+    ` + "```" + `text { output-of=greet }
+    hello
+    ` + "```" + `
+
+Stdout and stderr are compared together. The "match=regex" attribute makes the
+expected output a regular expression matching the whole output. The "ignore"
+attribute takes a comma-separated list of "whitespace" and "ansi" to ignore
+differences in whitespace and ANSI escape sequences.
+
+Without arguments, all commands with expectations are run. Commands they need
+are run before them. With --update, expected outputs are replaced with actual
+outputs, except for regular expressions.`,
+		ValidArgsFunction: validCmdNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readMarkdownFile(nil)
+			if err != nil {
+				return err
+			}
+
+			node, _, err := document.New(data, cmark.Render).Parse()
+			if err != nil {
+				return err
+			}
+
+			expectations, err := doctest.Collect(document.CollectCodeBlocks(node))
+			if err != nil {
+				return err
+			}
+
+			blocks, fmatter, err := parseDocument(data)
+			if err != nil {
+				return err
+			}
+			opts.frontmatter = fmatter
+
+			byName := make(map[string]*doctest.Expectation, len(expectations))
+			for _, e := range expectations {
+				byName[e.Name] = e
+			}
+
+			var selected document.CodeBlocks
+			if len(args) > 0 {
+				selected, err = selectCodeBlocks(blocks, args, false, "")
+				if err != nil {
+					return err
+				}
+			} else {
+				if len(expectations) == 0 {
+					return errors.New("no commands with expectations found; use expect-exit, expect-output, or an output block with output-of")
+				}
+				for _, e := range expectations {
+					block, err := lookupCodeBlock(blocks, e.Name)
+					if err != nil {
+						return err
+					}
+					selected = append(selected, block)
+				}
+			}
+
+			tested := make(map[string]*doctest.Expectation, len(selected))
+			for _, block := range selected {
+				e, ok := byName[block.Name()]
+				if !ok {
+					e = &doctest.Expectation{Name: block.Name()}
+				}
+				tested[block.Name()] = e
+			}
+
+			plan, err := resolvePlan(blocks, selected, false)
+			if err != nil {
+				return err
+			}
+
+			opts.vars, err = parseVars(opts.varPairs)
+			if err != nil {
+				return err
+			}
+
+			if err := checkTrust(cmd, plan, &opts); err != nil {
+				return err
+			}
+
+			ctx, cancel := ctxWithSigCancel(cmd.Context())
+			defer cancel()
+
+			var (
+				w       = cmd.OutOrStdout()
+				failed  = make(map[string]bool)
+				outputs = make(map[string]string)
+				total   int
+				fails   int
+			)
+
+			for _, block := range plan {
+				e, isTest := tested[block.Name()]
+				if isTest {
+					total++
+				}
+
+				if reason := skipReason(ctx, block, failed); reason != "" {
+					failed[block.Name()] = true
+					if isTest {
+						fails++
+						_, _ = fmt.Fprintf(w, "SKIP %s: %s\n", block.Name(), reason)
+					}
+					continue
+				}
+
+				start := time.Now()
+				exitCode, output := runTestBlock(ctx, block, &opts)
+				duration := time.Since(start).Round(time.Millisecond)
+
+				if !isTest {
+					if exitCode != 0 {
+						failed[block.Name()] = true
+						_, _ = fmt.Fprintf(w, "FAIL %s (%s)\n", block.Name(), duration)
+						printIndented(w, fmt.Sprintf("exit code %d\n%s", exitCode, output))
+					}
+					continue
+				}
+
+				var mismatches []string
+				if msg := e.CheckExitCode(exitCode); msg != "" {
+					mismatches = append(mismatches, msg)
+				}
+				if msg := e.CheckOutput(output); msg != "" {
+					if update && e.Updatable() {
+						outputs[e.Name] = e.Actual(output)
+					} else {
+						mismatches = append(mismatches, msg)
+					}
+				}
+				if len(mismatches) > 0 && !e.HasOutput {
+					mismatches = append(mismatches, "output:\n"+output)
+				}
+
+				if len(mismatches) == 0 {
+					_, _ = fmt.Fprintf(w, "PASS %s (%s)\n", block.Name(), duration)
+					continue
+				}
+
+				fails++
+				failed[block.Name()] = true
+				_, _ = fmt.Fprintf(w, "FAIL %s (%s)\n", block.Name(), duration)
+				for _, msg := range mismatches {
+					printIndented(w, msg)
+				}
+			}
+
+			if len(outputs) > 0 {
+				updated, err := doctest.Update(data, outputs)
+				if err != nil {
+					return err
+				}
+				if err := writeMarkdownFile(nil, updated); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(w, "updated expected output of %d commands\n", len(outputs))
+			}
+
+			if fails > 0 {
+				return errors.Errorf("%d of %d tests failed", fails, total)
+			}
+
+			return nil
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVarP(&update, "update", "u", false, "Replace expected outputs with actual outputs.")
+	cmd.Flags().DurationVar(&opts.gracePeriod, "grace-period", runner.DefaultGracePeriod, "Time given to commands to exit after an interrupt or timeout before they are killed.")
+	cmd.Flags().StringArrayVar(&opts.varPairs, "var", nil, "Set a value of a placeholder or variable as KEY=VALUE.")
+	cmd.Flags().BoolVar(&opts.nonInteractive, "non-interactive", false, "Fail instead of asking to approve new or changed commands.")
+
+	return &cmd
+}
+
+// runTestBlock runs the block with stdout and stderr
+// captured together and returns its exit code and output.
+func runTestBlock(ctx context.Context, block *document.CodeBlock, opts *runCmdOpts) (int, string) {
+	// The same writer is used for stdout and stderr so that
+	// exec.Cmd writes to it from a single goroutine.
+	var buf bytes.Buffer
+
+	result, err := runBlock(ctx, block, opts, blockStreams{stdout: &buf, stderr: &buf})
+
+	code := exitCode(result, err)
+	if err != nil && code < 0 {
+		_, _ = fmt.Fprintf(&buf, "%s\n", err)
+	}
+
+	return code, buf.String()
+}
+
+// skipReason returns why the block should not be run
+// or an empty string if it should.
+func skipReason(ctx context.Context, block *document.CodeBlock, failed map[string]bool) string {
+	if ctx.Err() != nil {
+		return "canceled"
+	}
+	for _, name := range block.Needs() {
+		if failed[name] {
+			return fmt.Sprintf("needs failed command %q", name)
+		}
+	}
+	return ""
+}
+
+func printIndented(w io.Writer, s string) {
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		_, _ = fmt.Fprintf(w, "    %s\n", line)
+	}
+}
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cli/cli/v2/pkg/iostreams"
+	"github.com/cli/cli/v2/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/doctest"
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/trust"
+	"github.com/stateful/runme/internal/tui"
+)
+
+func getTrustStore() *trust.Store {
+	return trust.NewStore(filepath.Join(getDefaultConfigHome(), "runme", "trust.json"))
+}
+
+// markdownSource identifies the markdown file in approvals.
+// It's an absolute path or, for remote files, the URL.
+func markdownSource() (string, error) {
+	if isRemoteMarkdown() {
+		return fFileName, nil
+	}
+	path, err := filepath.Abs(filepath.Join(fChdir, fFileName))
+	return path, errors.WithStack(err)
+}
+
+// finalScript returns the script of the block after replacements
+// without modifying the block.
+func finalScript(block *document.CodeBlock, replaceScripts []string) (string, error) {
+	lines := append([]string(nil), block.Lines()...)
+	if err := replace(replaceScripts, lines); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// executionAttributes are attributes of blocks which affect
+// how they are executed and hence are a part of approvals.
+var executionAttributes = []string{"cwd", "env", "env-file", "interpreter", "shell"}
+
+// reviewedCommand is what the user approves: the final script, which is
+// executed, and settings which affect how it's executed.
+type reviewedCommand struct {
+	// Settings are attributes and front matter properties
+	// formatted as name="value".
+	Settings []string `json:"settings,omitempty"`
+	Script   string   `json:"script"`
+}
+
+func newReviewedCommand(block *document.CodeBlock, fmatter document.Frontmatter, replaceScripts []string) (reviewedCommand, error) {
+	script, err := finalScript(block, replaceScripts)
+	if err != nil {
+		return reviewedCommand{}, err
+	}
+
+	c := reviewedCommand{Script: script}
+
+	add := func(name, value string) {
+		c.Settings = append(c.Settings, fmt.Sprintf("%s=%q", name, value))
+	}
+
+	attrs := block.Attributes()
+	for _, name := range executionAttributes {
+		if value, ok := attrs[name]; ok {
+			add(name, value)
+		}
+	}
+
+	if fmatter.Cwd != "" {
+		add("frontmatter.cwd", fmatter.Cwd)
+	}
+	if fmatter.EnvFile != "" {
+		add("frontmatter.env-file", fmatter.EnvFile)
+	}
+	for _, key := range sortedKeys(fmatter.Env) {
+		add("frontmatter.env."+key, fmatter.Env[key])
+	}
+	for _, key := range sortedKeys(fmatter.Interpreters) {
+		add("frontmatter.interpreters."+key, fmatter.Interpreters[key])
+	}
+
+	return c, nil
+}
+
+// Hash returns a hash of the settings and the script. They are encoded
+// as JSON so that moving text between them changes the hash.
+func (c reviewedCommand) Hash() string {
+	data, _ := json.Marshal(c)
+	return trust.Hash(string(data))
+}
+
+// String returns the settings as comments followed by the script.
+func (c reviewedCommand) String() string {
+	var b strings.Builder
+	for _, s := range c.Settings {
+		_, _ = fmt.Fprintf(&b, "# %s\n", s)
+	}
+	_, _ = b.WriteString(c.Script)
+	return b.String()
+}
+
+// checkTrust makes sure that the blocks' scripts, together with settings
+// affecting their execution, were approved before.
+// Commands from local files are approved automatically on their first run.
+// Commands from remote files and commands which changed since they were
+// approved are shown to the user who is asked to approve them.
+// If stdin is not a terminal or opts.nonInteractive is true, an error
+// is returned instead.
+func checkTrust(cmd *cobra.Command, plan document.CodeBlocks, opts *runCmdOpts) error {
+	source, err := markdownSource()
+	if err != nil {
+		return err
+	}
+
+	store := getTrustStore()
+
+	for _, block := range plan {
+		reviewed, err := newReviewedCommand(block, opts.frontmatter, opts.replaceScripts)
+		if err != nil {
+			return err
+		}
+		script := reviewed.String()
+
+		approval := trust.Approval{
+			Source: source,
+			Name:   block.Name(),
+			Hash:   reviewed.Hash(),
+			Script: script,
+			Time:   time.Now(),
+		}
+
+		prev, approved, err := store.Get(source, block.Name())
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case approved && prev.Hash == approval.Hash:
+			continue
+		case !approved && !isRemoteMarkdown():
+			if err := store.Approve(approval); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if opts.nonInteractive || !isInteractive(cmd.InOrStdin()) {
+			if approved {
+				return errors.Errorf("command %q changed since it was approved; run it in a terminal to review the changes", block.Name())
+			}
+			return errors.Errorf("command %q from %s has not been approved; run it in a terminal to review it", block.Name(), source)
+		}
+
+		stderr := cmd.ErrOrStderr()
+		if approved {
+			_, _ = fmt.Fprintf(stderr, "Command %q changed since it was approved on %s:\n\n", block.Name(), prev.Time.Local().Format(time.RFC1123))
+			_, _ = fmt.Fprintln(stderr, doctest.LabeledDiff("approved", "current", prev.Script, script))
+		} else {
+			_, _ = fmt.Fprintf(stderr, "Command %q from %s has not been approved:\n\n", block.Name(), source)
+			_, _ = fmt.Fprintf(stderr, "%s\n\n", script)
+		}
+
+		ok, err := promptForConfirmation(cmd, fmt.Sprintf("Do you approve running %q?", block.Name()))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.Errorf("command %q was not approved", block.Name())
+		}
+
+		if err := store.Approve(approval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// promptForConfirmation asks a yes/no question.
+func promptForConfirmation(cmd *cobra.Command, question string) (bool, error) {
+	model := tui.NewStandaloneQuestionModel(
+		question,
+		tui.MinimalKeyMap,
+		tui.DefaultStyles,
+	)
+	finalModel, err := newProgram(cmd, model).Run()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to prompt")
+	}
+	return finalModel.(tui.StandaloneQuestionModel).Confirmed(), nil
+}
+
+func trustCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "trust",
+		Short: "Manage approved commands.",
+		Long: `Manage approved commands.
+
+Before running a command, runme checks whether its script, after
+replacements, was approved for the markdown file or URL it comes from.
+Attributes affecting the execution (cwd, env, env-file, interpreter, shell)
+and the front matter's settings are a part of the approval.
+Commands from local files are approved on their first run. Commands from
+remote files, and commands changed since they were approved, are shown
+with a diff and must be confirmed.`,
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.AddCommand(trustListCmd())
+	cmd.AddCommand(trustRevokeCmd())
+
+	return &cmd
+}
+
+func trustListCmd() *cobra.Command {
+	var formatJSON bool
+
+	cmd := cobra.Command{
+		Use:   "list",
+		Short: "List approved commands.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			approvals, err := getTrustStore().List()
+			if err != nil {
+				return err
+			}
+
+			if formatJSON {
+				if approvals == nil {
+					approvals = []trust.Approval{}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return errors.Wrap(enc.Encode(approvals), "failed to encode to JSON")
+			}
+
+			// TODO: this should be taken from cmd.
+			io := iostreams.System()
+			//lint:ignore SA1019 utils is deprecated but that's ok for now.
+			table := utils.NewTablePrinter(io)
+
+			table.AddField(strings.ToUpper("Name"), nil, nil)
+			table.AddField(strings.ToUpper("Hash"), nil, nil)
+			table.AddField(strings.ToUpper("Approved"), nil, nil)
+			table.AddField(strings.ToUpper("Source"), nil, nil)
+			table.EndRow()
+
+			for _, a := range approvals {
+				table.AddField(a.Name, nil, nil)
+				table.AddField(shortHash(a.Hash), nil, nil)
+				table.AddField(a.Time.Local().Format(time.RFC3339), nil, nil)
+				table.AddField(a.Source, nil, nil)
+				table.EndRow()
+			}
+
+			return errors.Wrap(table.Render(), "failed to render")
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	cmd.Flags().BoolVar(&formatJSON, "json", false, "Print out approvals as JSON.")
+
+	return &cmd
+}
+
+// shortHash strips the algorithm and shortens the hash for display.
+func shortHash(hash string) string {
+	_, hex, ok := strings.Cut(hash, ":")
+	if !ok {
+		hex = hash
+	}
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
+
+func trustRevokeCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "revoke [NAME...]",
+		Short: "Revoke approvals of commands.",
+		Long: `Revoke approvals of the named commands from the markdown file
+selected with --chdir and --filename. Without names, all approvals
+of commands from the file are revoked.`,
+		ValidArgsFunction: validCmdNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source, err := markdownSource()
+			if err != nil {
+				return err
+			}
+
+			n, err := getTrustStore().Revoke(source, args...)
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "runme: revoked %d approval(s) of commands from %s\n", n, source)
+
+			return nil
+		},
+	}
+
+	setDefaultFlags(&cmd)
+
+	return &cmd
+}
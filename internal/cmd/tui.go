@@ -189,6 +189,7 @@ func tuiCmd() *cobra.Command {
 	var (
 		numEntries   int
 		exitAfterRun bool
+		runOpts      runCmdOpts
 	)
 
 	cmd := cobra.Command{
@@ -196,10 +197,11 @@ func tuiCmd() *cobra.Command {
 		Short: "Run the interactive TUI.",
 		Long:  "Run a command from a descriptive list given by an interactive TUI.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			blocks, err := getCodeBlocks()
+			blocks, fmatter, err := getDocument()
 			if err != nil {
 				return err
 			}
+			runOpts.frontmatter = fmatter
 
 			if len(blocks) == 0 {
 				return errors.Errorf("no code blocks in %s", fFileName)
@@ -238,7 +240,16 @@ func tuiCmd() *cobra.Command {
 					break
 				}
 
-				if err = runBlock(cmd, result.block, nil); err != nil {
+				err = checkTrust(cmd, document.CodeBlocks{result.block}, &runOpts)
+				if err == nil {
+					err = confirmBlocks(cmd, document.CodeBlocks{result.block}, &runOpts)
+				}
+				if err == nil {
+					ctx, cancel := ctxWithSigCancel(cmd.Context())
+					_, err = runBlock(ctx, result.block, &runOpts, cmdStreams(cmd))
+					cancel()
+				}
+				if err != nil {
 					if _, err := fmt.Printf(ansi.Color("%v", "red")+"\n", err); err != nil {
 						return err
 					}
@@ -263,6 +274,9 @@ func tuiCmd() *cobra.Command {
 
 	cmd.Flags().BoolVar(&exitAfterRun, "exit", false, "Exit runme TUI after running a command.")
 	cmd.Flags().IntVar(&numEntries, "entries", defaultNumEntries, "Number of entries to show in TUI.")
+	cmd.Flags().StringVar(&runOpts.session, "session", "", "Persist the environment and working directory of shell commands in a named session.")
+	cmd.Flags().BoolVarP(&runOpts.yes, "yes", "y", false, "Run commands requiring confirmation without asking.")
+	cmd.Flags().BoolVar(&runOpts.redact, "redact", false, "Mask secrets, like tokens and passwords, in the output.")
 
 	return &cmd
 }
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/runner"
+	"github.com/stateful/runme/internal/tui"
+)
+
+// parseVars parses KEY=VALUE pairs passed with --var.
+func parseVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, errors.Errorf("invalid var %q: expected KEY=VALUE", pair)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// varsPath returns the path of a file with remembered values
+// of variables. Each project has a separate file.
+func varsPath() (string, error) {
+	id, err := projectID()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(getDefaultConfigHome(), "runme", "vars", id+".json"), nil
+}
+
+func loadRememberedVars() (map[string]string, error) {
+	path, err := varsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return vars, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read remembered vars")
+	}
+
+	if err := json.Unmarshal(data, &vars); err != nil {
+		return nil, errors.Wrap(err, "failed to parse remembered vars")
+	}
+	return vars, nil
+}
+
+func saveRememberedVars(vars map[string]string) error {
+	path, err := varsPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(vars)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create vars dir")
+	}
+
+	return errors.Wrap(os.WriteFile(path, data, 0o600), "failed to write remembered vars")
+}
+
+// writeVarsFile writes vars to a new file in dir
+// which only the current user can read.
+func writeVarsFile(dir string, vars map[string]string) (string, error) {
+	data, err := json.Marshal(vars)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	f, err := os.CreateTemp(dir, "vars-*.json")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create vars file")
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(data); err != nil {
+		_ = os.Remove(f.Name())
+		return "", errors.Wrap(err, "failed to write vars file")
+	}
+	return f.Name(), nil
+}
+
+// readVarsFile reads vars written by writeVarsFile and removes the file.
+func readVarsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read vars file")
+	}
+	_ = os.Remove(path)
+
+	vars := make(map[string]string)
+	if err := json.Unmarshal(data, &vars); err != nil {
+		return nil, errors.Wrap(err, "failed to parse vars file")
+	}
+	return vars, nil
+}
+
+// resolveVariables fills in placeholders and exports without values
+// in a shell block. Values come from opts.vars or, if stdin is a terminal,
+// are prompted for and added to opts.vars. Otherwise, a missing value is
+// an error, except in a dry run which leaves the variable in place.
+// References to variables not present in env are resolved in the same way,
+// but stay unset without a value, and are returned as KEY=VALUE pairs
+// which should be added to the environment.
+func resolveVariables(block *document.CodeBlock, env []string, opts *runCmdOpts, streams blockStreams) ([]string, error) {
+	if !runner.IsShell(block) {
+		return nil, nil
+	}
+
+	if opts.vars == nil {
+		opts.vars = make(map[string]string)
+	}
+
+	var (
+		refs     []string
+		answered bool
+	)
+
+	for _, v := range block.Variables() {
+		if v.Kind == document.ReferenceVariable && hasEnv(env, v.Name) {
+			continue
+		}
+
+		value, ok := opts.vars[v.Name]
+		if !ok {
+			if opts.dryRun {
+				continue
+			}
+
+			if !isInteractive(streams.stdin) {
+				if v.Kind == document.ReferenceVariable {
+					continue
+				}
+				return nil, errors.Errorf("missing value for %q; pass --var %s=...", v.Name, v.Name)
+			}
+
+			var err error
+			value, err = promptForVariable(v, streams)
+			if err != nil {
+				return nil, err
+			}
+
+			opts.vars[v.Name] = value
+			answered = true
+		}
+
+		if v.Kind == document.ReferenceVariable {
+			refs = append(refs, v.Name+"="+value)
+		}
+	}
+
+	block.ResolveVariables(opts.vars)
+
+	if answered && opts.rememberVars {
+		if err := saveRememberedVars(opts.vars); err != nil {
+			return nil, err
+		}
+	}
+
+	return refs, nil
+}
+
+func promptForVariable(v document.Variable, streams blockStreams) (string, error) {
+	model := tui.NewStandaloneInputModel("Enter a value for "+v.String()+":", tui.MinimalKeyMap, tui.DefaultStyles)
+	finalModel, err := tea.NewProgram(
+		model,
+		tea.WithInput(streams.stdin),
+		tea.WithOutput(streams.stderr),
+	).Run()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	val, ok := finalModel.(tui.StandaloneInputModel).Value()
+	if !ok {
+		return "", errors.New("canceled")
+	}
+	return val, nil
+}
+
+func isInteractive(stdin io.Reader) bool {
+	f, ok := stdin.(*os.File)
+	return ok && isTerminal(f.Fd())
+}
+
+func hasEnv(env []string, key string) bool {
+	for _, kv := range env {
+		if strings.HasPrefix(kv, key+"=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stateful/runme/internal/watch"
+)
+
+// clearScreen moves the cursor to the top left corner
+// and clears the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// watchAndRun calls run and calls it again whenever the markdown file
+// or files under --chdir matching pattern change. While run is in progress,
+// only changes of the markdown file cancel and restart it. Changes of other
+// files, which might be written by the command itself, are discarded until
+// it finishes. It returns when ctx is done.
+func watchAndRun(ctx context.Context, cmd *cobra.Command, pattern string, run func(context.Context) error) error {
+	file := filepath.ToSlash(filepath.Clean(fFileName))
+
+	isFile := func(name string) bool { return name == file }
+	matches := func(name string) bool { return isFile(name) || watch.Match(pattern, name) }
+
+	w, err := watch.New(fChdir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	stdout, stderr := cmd.OutOrStdout(), cmd.ErrOrStderr()
+
+	clear := false
+	if f, ok := stdout.(*os.File); ok {
+		clear = isTerminal(f.Fd())
+	}
+
+	for {
+		runCtx, cancelRun := context.WithCancel(ctx)
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			err := run(runCtx)
+			if runCtx.Err() != nil {
+				return
+			}
+			if err != nil {
+				_, _ = fmt.Fprintln(stderr, err.Error())
+			}
+		}()
+
+		changed, err := waitWhileRunning(ctx, w, done, isFile)
+
+		cancelRun()
+		<-done
+
+		if err != nil {
+			return watchError(ctx, err)
+		}
+
+		if !changed {
+			_, _ = fmt.Fprintln(stderr, "runme: waiting for changes...")
+			if err := w.Wait(ctx, matches); err != nil {
+				return watchError(ctx, err)
+			}
+		}
+
+		if clear {
+			_, _ = fmt.Fprint(stdout, clearScreen)
+		}
+	}
+}
+
+// waitWhileRunning waits until a file matching match changes, in which
+// case changed is true, or done is closed. In the latter case, changes
+// made by the run are discarded.
+func waitWhileRunning(ctx context.Context, w *watch.Watcher, done <-chan struct{}, match watch.MatchFunc) (changed bool, _ error) {
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-waitCtx.Done():
+		}
+	}()
+
+	err := w.Wait(waitCtx, match)
+	if err == nil {
+		return true, nil
+	}
+	if ctx.Err() != nil || !errors.Is(err, context.Canceled) {
+		return false, err
+	}
+
+	return false, w.Drain(ctx)
+}
+
+// watchError returns nil if watching was interrupted by the user.
+func watchError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
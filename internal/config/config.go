@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/danger"
+	"github.com/stateful/runme/internal/runner"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is a name of the config file
+// looked up in a project directory.
+const FileName = ".runme.yaml"
+
+type Config struct {
+	// Interpreters maps a language to a command template
+	// used to run its code blocks.
+	Interpreters map[string]runner.Interpreter `yaml:"interpreters"`
+	// Danger lists rules detecting destructive commands in addition
+	// to the built-in ones. A rule with the name of a built-in rule
+	// replaces it, or disables it if its pattern is empty.
+	Danger []danger.Rule `yaml:"danger"`
+	// Secrets configures masking of secrets in the output of commands.
+	Secrets Secrets `yaml:"secrets"`
+}
+
+// Secrets configures which values are masked. Besides them,
+// well-known token formats are always masked.
+type Secrets struct {
+	// Redact, if true, masks secrets in the output of all commands.
+	Redact *bool `yaml:"redact"`
+	// Env lists glob patterns, like "*_TOKEN", matching names
+	// of environment variables with secret values.
+	// If empty, redact.DefaultEnvPatterns are used.
+	Env []string `yaml:"env"`
+	// Files lists files with secret values, one per line.
+	// Relative paths are relative to the config file.
+	Files []string `yaml:"files"`
+}
+
+// Load reads and merges config files. Entries from later files
+// take precedence over entries from earlier ones. Files that
+// do not exist are skipped.
+func Load(paths ...string) (*Config, error) {
+	result := &Config{
+		Interpreters: make(map[string]runner.Interpreter),
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read config %s", path)
+		}
+
+		var cfg Config
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse config %s", path)
+		}
+
+		for lang, interpreter := range cfg.Interpreters {
+			if err := interpreter.Validate(); err != nil {
+				return nil, errors.Wrapf(err, "invalid interpreter %q in %s", lang, path)
+			}
+			result.Interpreters[lang] = interpreter
+		}
+
+		if _, err := danger.NewDetector(cfg.Danger...); err != nil {
+			return nil, errors.Wrapf(err, "invalid danger rules in %s", path)
+		}
+		result.Danger = append(result.Danger, cfg.Danger...)
+
+		if cfg.Secrets.Redact != nil {
+			result.Secrets.Redact = cfg.Secrets.Redact
+		}
+		for _, pattern := range cfg.Secrets.Env {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid secrets env pattern %q in %s", pattern, path)
+			}
+			result.Secrets.Env = append(result.Secrets.Env, pattern)
+		}
+		for _, name := range cfg.Secrets.Files {
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(filepath.Dir(path), name)
+			}
+			result.Secrets.Files = append(result.Secrets.Files, name)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stateful/runme/internal/danger"
+	"github.com/stateful/runme/internal/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	userConfig := filepath.Join(dir, "user.yaml")
+	err := os.WriteFile(userConfig, []byte(`
+interpreters:
+  ruby:
+    command: ruby
+    extension: rb
+  lua:
+    command: lua
+    input: stdin
+danger:
+  - name: prod
+    pattern: --env=prod
+secrets:
+  redact: true
+  env: ["*_TOKEN"]
+  files: [user-secrets]
+`), 0o600)
+	require.NoError(t, err)
+
+	projectConfig := filepath.Join(dir, FileName)
+	err = os.WriteFile(projectConfig, []byte(`
+interpreters:
+  ruby:
+    command: ruby
+    args: [-w]
+    input: arg
+danger:
+  - name: git-reset-hard
+  - name: prod
+    pattern: --env=(prod|production)
+    description: deploys to production
+secrets:
+  redact: false
+  env: [DB_URL]
+  files: [.secrets]
+`), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := Load(userConfig, projectConfig, filepath.Join(dir, "missing.yaml"))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[string]runner.Interpreter{
+			"ruby": {Command: "ruby", Args: []string{"-w"}, Input: runner.InputArg},
+			"lua":  {Command: "lua", Input: runner.InputStdin},
+		},
+		cfg.Interpreters,
+	)
+	assert.Equal(
+		t,
+		[]danger.Rule{
+			{Name: "prod", Pattern: "--env=prod"},
+			{Name: "git-reset-hard"},
+			{Name: "prod", Pattern: "--env=(prod|production)", Description: "deploys to production"},
+		},
+		cfg.Danger,
+	)
+	require.NotNil(t, cfg.Secrets.Redact)
+	assert.False(t, *cfg.Secrets.Redact)
+	assert.Equal(t, []string{"*_TOKEN", "DB_URL"}, cfg.Secrets.Env)
+	assert.Equal(t, []string{filepath.Join(dir, "user-secrets"), filepath.Join(dir, ".secrets")}, cfg.Secrets.Files)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	err := os.WriteFile(path, []byte("interpreters:\n  perl:\n    input: pipe\n"), 0o600)
+	require.NoError(t, err)
+
+	_, err = Load(path)
+	require.Error(t, err)
+
+	err = os.WriteFile(path, []byte("danger:\n  - name: broken\n    pattern: '('\n"), 0o600)
+	require.NoError(t, err)
+
+	_, err = Load(path)
+	assert.ErrorContains(t, err, `invalid pattern of rule "broken"`)
+
+	err = os.WriteFile(path, []byte("secrets:\n  env: ['[']\n"), 0o600)
+	require.NoError(t, err)
+
+	_, err = Load(path)
+	assert.ErrorContains(t, err, `invalid secrets env pattern "["`)
+}
@@ -0,0 +1,136 @@
+// Package danger detects destructive commands, like removing files
+// or dropping database tables, in code blocks using regular expressions.
+package danger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Rule describes a kind of destructive commands.
+type Rule struct {
+	Name string `yaml:"name"`
+	// Pattern is a regular expression matched against each line.
+	Pattern string `yaml:"pattern"`
+	// Description explains why matching commands are dangerous.
+	Description string `yaml:"description,omitempty"`
+}
+
+// DefaultRules are the built-in rules.
+var DefaultRules = []Rule{
+	{
+		Name:        "rm-recursive",
+		Pattern:     `\brm\s+(-\S*\s+)*(-[a-zA-Z]*[rR][a-zA-Z]*|--recursive)\b`,
+		Description: "removes files recursively",
+	},
+	{
+		Name:        "kubectl-delete",
+		Pattern:     `\bkubectl\s+([^\s|;&]+\s+)*delete\b`,
+		Description: "deletes Kubernetes resources",
+	},
+	{
+		Name:        "helm-uninstall",
+		Pattern:     `\bhelm\s+([^\s|;&]+\s+)*(uninstall|delete)\b`,
+		Description: "uninstalls Helm releases",
+	},
+	{
+		Name:        "terraform-destroy",
+		Pattern:     `\bterraform\s+([^\s|;&]+\s+)*(destroy\b|apply\s+([^\s|;&]+\s+)*-destroy\b)`,
+		Description: "destroys infrastructure",
+	},
+	{
+		Name:        "sql-drop",
+		Pattern:     `(?i)\bdrop\s+(table|database|schema)\b`,
+		Description: "drops database objects",
+	},
+	{
+		Name:        "sql-truncate",
+		Pattern:     `(?i)\btruncate\s+table\b`,
+		Description: "deletes all rows of a table",
+	},
+	{
+		Name:        "git-force-push",
+		Pattern:     `\bgit\s+push\s+([^\s|;&]+\s+)*(-f\b|--force\b|--force-with-lease\b)`,
+		Description: "overwrites remote history",
+	},
+	{
+		Name:        "git-reset-hard",
+		Pattern:     `\bgit\s+reset\s+([^\s|;&]+\s+)*--hard\b`,
+		Description: "discards local changes",
+	},
+	{
+		Name:        "disk-overwrite",
+		Pattern:     `\b(mkfs(\.\w+)?\s|dd\s+([^\s|;&]+\s+)*of=/dev/)`,
+		Description: "overwrites a disk",
+	},
+}
+
+// Match is a line matched by a rule.
+type Match struct {
+	Rule Rule
+	// Line is the line's number starting from 1.
+	Line int
+	Text string
+}
+
+// Detector matches lines against rules.
+type Detector struct {
+	rules    []Rule
+	patterns []*regexp.Regexp
+}
+
+// NewDetector creates a detector using the rules. Rules are identified
+// by names. Later rules replace earlier ones with the same name and
+// rules with empty patterns disable them.
+func NewDetector(rules ...Rule) (*Detector, error) {
+	d := &Detector{}
+
+	indexes := make(map[string]int)
+
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, errors.Errorf("rule with pattern %q has no name", rule.Pattern)
+		}
+
+		var pattern *regexp.Regexp
+		if rule.Pattern != "" {
+			var err error
+			pattern, err = regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid pattern of rule %q", rule.Name)
+			}
+		}
+
+		if idx, ok := indexes[rule.Name]; ok {
+			d.rules[idx], d.patterns[idx] = rule, pattern
+			continue
+		}
+
+		indexes[rule.Name] = len(d.rules)
+		d.rules = append(d.rules, rule)
+		d.patterns = append(d.patterns, pattern)
+	}
+
+	return d, nil
+}
+
+// Check returns lines matching any rule. Each line
+// is reported at most once, with the first rule it matches.
+func (d *Detector) Check(lines []string) []Match {
+	var result []Match
+
+	for idx, line := range lines {
+		text := strings.TrimSpace(line)
+
+		for i, pattern := range d.patterns {
+			if pattern != nil && pattern.MatchString(text) {
+				result = append(result, Match{Rule: d.rules[i], Line: idx + 1, Text: text})
+				break
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,97 @@
+package danger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRules(t *testing.T) {
+	d, err := NewDetector(DefaultRules...)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		line string
+		rule string
+	}{
+		{"rm -rf build", "rm-recursive"},
+		{"sudo rm -fr /var/cache/app", "rm-recursive"},
+		{"rm -f -R dist", "rm-recursive"},
+		{"rm --recursive node_modules", "rm-recursive"},
+		{"rm -f out.txt", ""},
+		{"rm file-r", ""},
+		{"kubectl delete pod web-0", "kubectl-delete"},
+		{"kubectl -n prod delete deployment api", "kubectl-delete"},
+		{"kubectl get pods | grep delete", ""},
+		{"helm uninstall my-release", "helm-uninstall"},
+		{"terraform destroy -auto-approve", "terraform-destroy"},
+		{"terraform -chdir=infra apply -destroy", "terraform-destroy"},
+		{"terraform apply", ""},
+		{`psql -c "DROP TABLE users;"`, "sql-drop"},
+		{"drop database app;", "sql-drop"},
+		{"TRUNCATE TABLE sessions;", "sql-truncate"},
+		{"git push --force origin main", "git-force-push"},
+		{"git push -f", "git-force-push"},
+		{"git push origin main", ""},
+		{"git reset --hard origin/main", "git-reset-hard"},
+		{"sudo mkfs.ext4 /dev/sdb1", "disk-overwrite"},
+		{"dd if=image.iso of=/dev/sdb bs=4M", "disk-overwrite"},
+		{"dd if=/dev/zero of=file.img", ""},
+		{"echo hello", ""},
+	}
+
+	for _, tc := range testCases {
+		matches := d.Check([]string{tc.line})
+		if tc.rule == "" {
+			assert.Empty(t, matches, tc.line)
+			continue
+		}
+		if assert.Len(t, matches, 1, tc.line) {
+			assert.Equal(t, tc.rule, matches[0].Rule.Name, tc.line)
+		}
+	}
+}
+
+func TestDetector_Check(t *testing.T) {
+	d, err := NewDetector(DefaultRules...)
+	require.NoError(t, err)
+
+	matches := d.Check([]string{
+		"cd build",
+		"  rm -rf * ",
+		"git reset --hard && rm -rf .",
+	})
+	assert.Equal(
+		t,
+		[]Match{
+			{Rule: DefaultRules[0], Line: 2, Text: "rm -rf *"},
+			{Rule: DefaultRules[0], Line: 3, Text: "git reset --hard && rm -rf ."},
+		},
+		matches,
+	)
+}
+
+func TestNewDetector(t *testing.T) {
+	rules := append(
+		DefaultRules[:len(DefaultRules):len(DefaultRules)],
+		Rule{Name: "rm-recursive"},
+		Rule{Name: "prod", Pattern: `--env=prod\b`, Description: "deploys to production"},
+		Rule{Name: "prod", Pattern: `--env=(prod|production)\b`, Description: "deploys to production"},
+	)
+
+	d, err := NewDetector(rules...)
+	require.NoError(t, err)
+
+	assert.Empty(t, d.Check([]string{"rm -rf build"}))
+
+	matches := d.Check([]string{"./deploy --env=production"})
+	require.Len(t, matches, 1)
+	assert.Equal(t, "prod", matches[0].Rule.Name)
+
+	_, err = NewDetector(Rule{Pattern: "x"})
+	assert.EqualError(t, err, `rule with pattern "x" has no name`)
+
+	_, err = NewDetector(Rule{Name: "broken", Pattern: "("})
+	assert.ErrorContains(t, err, `invalid pattern of rule "broken"`)
+}
@@ -0,0 +1,59 @@
+package doctest
+
+import (
+	"strings"
+)
+
+// Diff returns a line by line difference between expected and actual.
+// Lines only in expected are prefixed with "-", lines only in actual
+// with "+", and common lines with a space.
+func Diff(expected, actual string) string {
+	return LabeledDiff("expected", "actual", expected, actual)
+}
+
+// LabeledDiff is like Diff but the compared texts
+// are labeled with fromLabel and toLabel.
+func LabeledDiff(fromLabel, toLabel, from, to string) string {
+	a := strings.Split(from, "\n")
+	b := strings.Split(to, "\n")
+
+	// lcs[i][j] is the length of the longest common
+	// subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("--- " + fromLabel + "\n+++ " + toLabel + "\n")
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,245 @@
+// Package doctest checks results of commands against expectations
+// written down next to them in markdown files.
+package doctest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/document"
+)
+
+type MatchMode string
+
+const (
+	// MatchExact requires the output to be equal to the expected one.
+	MatchExact MatchMode = "exact"
+	// MatchRegex requires the whole output to match
+	// the expected one used as a regular expression.
+	MatchRegex MatchMode = "regex"
+)
+
+// Expectation is the expected result of a command. It is defined by
+// "expect-exit" and "expect-output" attributes of the command and
+// by an output block with the "output-of" attribute set to its name.
+//
+// The "match" attribute selects a MatchMode and the "ignore" attribute
+// takes a comma-separated list of "whitespace" and "ansi".
+type Expectation struct {
+	// Name is the name of the command.
+	Name     string
+	ExitCode int
+	// Output is the expected output. It's compared
+	// only if HasOutput is true.
+	Output    string
+	HasOutput bool
+	// FromOutputBlock is true if Output comes from an output block
+	// and false if it comes from the "expect-output" attribute.
+	FromOutputBlock  bool
+	Match            MatchMode
+	IgnoreWhitespace bool
+	IgnoreANSI       bool
+
+	re *regexp.Regexp
+}
+
+// Collect returns expectations of blocks in the order
+// of the commands in the document. Blocks should include
+// code blocks in all languages as output blocks are often
+// marked as text.
+func Collect(blocks document.CodeBlocks) ([]*Expectation, error) {
+	var (
+		result []*Expectation
+		byName = make(map[string]*Expectation)
+	)
+
+	get := func(name string) *Expectation {
+		e, ok := byName[name]
+		if !ok {
+			e = &Expectation{Name: name, Match: MatchExact}
+			byName[name] = e
+		}
+		return e
+	}
+
+	for _, block := range blocks {
+		if block.OutputOf() != "" {
+			continue
+		}
+
+		attrs := block.Attributes()
+
+		exitCode, hasExitCode := attrs["expect-exit"]
+		output, hasOutput := attrs["expect-output"]
+		if !hasExitCode && !hasOutput {
+			continue
+		}
+
+		e := get(block.Name())
+
+		if hasExitCode {
+			code, err := strconv.Atoi(exitCode)
+			if err != nil {
+				return nil, errors.Errorf("invalid expect-exit %q of command %q: expected a number", exitCode, block.Name())
+			}
+			e.ExitCode = code
+		}
+
+		if hasOutput {
+			e.Output = output
+			e.HasOutput = true
+			if err := e.setOptions(attrs); err != nil {
+				return nil, errors.Wrapf(err, "invalid expectation of command %q", block.Name())
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		name := block.OutputOf()
+		if name == "" {
+			continue
+		}
+
+		target := blocks.Lookup(name)
+		if target == nil || target.OutputOf() != "" {
+			return nil, errors.Errorf("output block refers to unknown command %q", name)
+		}
+
+		e := get(name)
+		if e.FromOutputBlock {
+			return nil, errors.Errorf("command %q has more than one output block", name)
+		}
+		if e.HasOutput {
+			return nil, errors.Errorf("command %q has both expect-output and an output block", name)
+		}
+
+		e.Output = string(block.Content())
+		e.HasOutput = true
+		e.FromOutputBlock = true
+		if err := e.setOptions(block.Attributes()); err != nil {
+			return nil, errors.Wrapf(err, "invalid output block of command %q", name)
+		}
+	}
+
+	for _, block := range blocks {
+		if e, ok := byName[block.Name()]; ok && block.OutputOf() == "" {
+			result = append(result, e)
+		}
+	}
+
+	return result, nil
+}
+
+func (e *Expectation) setOptions(attrs map[string]string) error {
+	if match, ok := attrs["match"]; ok {
+		switch mode := MatchMode(match); mode {
+		case MatchExact, MatchRegex:
+			e.Match = mode
+		default:
+			return errors.Errorf("unsupported match %q; supported values: exact, regex", match)
+		}
+	}
+
+	for _, item := range strings.Split(attrs["ignore"], ",") {
+		switch strings.TrimSpace(item) {
+		case "":
+		case "whitespace":
+			e.IgnoreWhitespace = true
+		case "ansi":
+			e.IgnoreANSI = true
+		default:
+			return errors.Errorf("unsupported ignore %q; supported values: whitespace, ansi", item)
+		}
+	}
+
+	if e.Match == MatchRegex {
+		re, err := regexp.Compile(`^(?:` + trimNewLines(e.Output) + `)$`)
+		if err != nil {
+			return errors.Wrap(err, "invalid regular expression")
+		}
+		e.re = re
+	}
+
+	return nil
+}
+
+// CheckExitCode returns a description of the mismatch
+// or an empty string if exitCode is the expected one.
+func (e *Expectation) CheckExitCode(exitCode int) string {
+	if exitCode == e.ExitCode {
+		return ""
+	}
+	return fmt.Sprintf("expected exit code %d, got %d", e.ExitCode, exitCode)
+}
+
+// CheckOutput returns a description of the mismatch or an empty
+// string if output matches the expected one. Trailing new lines
+// are never significant.
+func (e *Expectation) CheckOutput(output string) string {
+	if !e.HasOutput {
+		return ""
+	}
+
+	actual := e.normalize(output)
+
+	if e.Match == MatchRegex {
+		if e.re.MatchString(actual) {
+			return ""
+		}
+		return fmt.Sprintf("output does not match %s:\n%s", e.re, actual)
+	}
+
+	expected := e.normalize(e.Output)
+	if actual == expected {
+		return ""
+	}
+
+	return "output differs:\n" + Diff(expected, actual)
+}
+
+// Updatable reports whether the expected output can be replaced
+// with the actual output. Regular expressions are never replaced.
+func (e *Expectation) Updatable() bool {
+	return e.HasOutput && e.Match != MatchRegex
+}
+
+// Actual returns output as it should be written
+// down in place of the expected output.
+func (e *Expectation) Actual(output string) string {
+	if e.IgnoreANSI {
+		output = stripANSI(output)
+	}
+	return trimNewLines(strings.ReplaceAll(output, "\r\n", "\n"))
+}
+
+var ansiRe = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+func stripANSI(s string) string {
+	return ansiRe.ReplaceAllString(s, "")
+}
+
+// normalize applies the ignore options. Ignoring whitespace trims
+// and collapses whitespace in each line and drops blank lines
+// so that differences are still reported line by line.
+func (e *Expectation) normalize(s string) string {
+	s = e.Actual(s)
+
+	if !e.IgnoreWhitespace {
+		return s
+	}
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines = append(lines, strings.Join(fields, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func trimNewLines(s string) string {
+	return strings.TrimRight(s, "\n")
+}
@@ -0,0 +1,168 @@
+package doctest
+
+import (
+	"testing"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/renderer/cmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseCodeBlocks(t *testing.T, data string) document.CodeBlocks {
+	t.Helper()
+	node, _, err := document.New([]byte(data), cmark.Render).Parse()
+	require.NoError(t, err)
+	return document.CollectCodeBlocks(node)
+}
+
+func TestCollect(t *testing.T) {
+	blocks := parseCodeBlocks(t, "```sh { name=build }\nmake\n```\n\n"+
+		"```text { output-of=build ignore=whitespace,ansi }\nok\n```\n\n"+
+		"```sh { name=fail expect-exit=2 }\nexit 2\n```\n\n"+
+		"```sh { name=version expect-output=v[0-9]+ match=regex }\necho v1\n```\n\n"+
+		"```sh { name=other }\necho 1\n```\n")
+
+	expectations, err := Collect(blocks)
+	require.NoError(t, err)
+	require.Len(t, expectations, 3)
+
+	build := expectations[0]
+	assert.Equal(t, "build", build.Name)
+	assert.Equal(t, 0, build.ExitCode)
+	assert.Equal(t, "ok", build.Output)
+	assert.True(t, build.FromOutputBlock)
+	assert.True(t, build.IgnoreWhitespace)
+	assert.True(t, build.IgnoreANSI)
+	assert.True(t, build.Updatable())
+
+	fail := expectations[1]
+	assert.Equal(t, "fail", fail.Name)
+	assert.Equal(t, 2, fail.ExitCode)
+	assert.False(t, fail.HasOutput)
+
+	version := expectations[2]
+	assert.Equal(t, "version", version.Name)
+	assert.Equal(t, MatchRegex, version.Match)
+	assert.False(t, version.FromOutputBlock)
+	assert.False(t, version.Updatable())
+}
+
+func TestCollect_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		data   string
+		errMsg string
+	}{
+		{
+			name:   "UnknownCommand",
+			data:   "```text { output-of=missing }\nok\n```\n",
+			errMsg: `output block refers to unknown command "missing"`,
+		},
+		{
+			name:   "DuplicateOutputBlock",
+			data:   "```sh { name=a }\necho\n```\n\n```text { output-of=a }\n1\n```\n\n```text { output-of=a }\n2\n```\n",
+			errMsg: `command "a" has more than one output block`,
+		},
+		{
+			name:   "InvalidExitCode",
+			data:   "```sh { name=a expect-exit=x }\necho\n```\n",
+			errMsg: `invalid expect-exit "x" of command "a": expected a number`,
+		},
+		{
+			name:   "InvalidMatch",
+			data:   "```sh { name=a }\necho\n```\n\n```text { output-of=a match=glob }\n1\n```\n",
+			errMsg: `invalid output block of command "a": unsupported match "glob"; supported values: exact, regex`,
+		},
+		{
+			name:   "InvalidRegex",
+			data:   "```sh { name=a expect-output=( match=regex }\necho\n```\n",
+			errMsg: "invalid expectation of command \"a\": invalid regular expression: error parsing regexp: missing closing ): `^(?:()$`",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Collect(parseCodeBlocks(t, tc.data))
+			assert.EqualError(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestExpectation_CheckOutput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		output   string
+		mismatch bool
+	}{
+		{
+			name:   "Exact",
+			data:   "```text { output-of=cmd }\nhello\nworld\n```\n",
+			output: "hello\nworld\n\n",
+		},
+		{
+			name:     "ExactMismatch",
+			data:     "```text { output-of=cmd }\nhello\nworld\n```\n",
+			output:   "hello  world\n",
+			mismatch: true,
+		},
+		{
+			name:   "IgnoreWhitespace",
+			data:   "```text { output-of=cmd ignore=whitespace }\nhello   world\n\n  bye\n```\n",
+			output: "hello world  \n  bye\n",
+		},
+		{
+			name:     "ANSI",
+			data:     "```text { output-of=cmd }\nhello\n```\n",
+			output:   "\x1b[32mhello\x1b[0m\n",
+			mismatch: true,
+		},
+		{
+			name:   "IgnoreANSI",
+			data:   "```text { output-of=cmd ignore=ansi }\nhello\n```\n",
+			output: "\x1b[32mhello\x1b[0m\n",
+		},
+		{
+			name:   "Regex",
+			data:   "```text { output-of=cmd match=regex }\nbuilt in \\d+ms\ndone\n```\n",
+			output: "built in 42ms\ndone\n",
+		},
+		{
+			name:     "RegexMatchesWholeOutput",
+			data:     "```text { output-of=cmd match=regex }\nbuilt\n```\n",
+			output:   "built in 42ms\n",
+			mismatch: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blocks := parseCodeBlocks(t, "```sh { name=cmd }\necho\n```\n\n"+tc.data)
+			expectations, err := Collect(blocks)
+			require.NoError(t, err)
+			require.Len(t, expectations, 1)
+
+			msg := expectations[0].CheckOutput(tc.output)
+			if tc.mismatch {
+				assert.NotEmpty(t, msg)
+			} else {
+				assert.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestExpectation_CheckExitCode(t *testing.T) {
+	e := &Expectation{Name: "cmd", ExitCode: 1}
+	assert.Empty(t, e.CheckExitCode(1))
+	assert.Equal(t, "expected exit code 1, got 0", e.CheckExitCode(0))
+}
+
+func TestDiff(t *testing.T) {
+	assert.Equal(
+		t,
+		"--- expected\n+++ actual\n  a\n- b\n+ B\n  c\n+ d\n",
+		Diff("a\nb\nc", "a\nB\nc\nd"),
+	)
+}
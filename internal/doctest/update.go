@@ -0,0 +1,108 @@
+package doctest
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/document/editor"
+	"github.com/stateful/runme/internal/renderer/cmark"
+)
+
+const (
+	cellNameKey       = "runme.dev/name"
+	expectOutputKey   = "expect-output"
+	outputOfAttribute = "output-of"
+)
+
+// Update replaces expected outputs in the markdown source with outputs
+// keyed by command names and returns the new source. Output blocks in
+// languages unknown to the editor are kept as markup cells, hence they
+// are parsed and rendered again here.
+func Update(source []byte, outputs map[string]string) ([]byte, error) {
+	notebook, err := editor.Deserialize(source)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to deserialize")
+	}
+
+	for _, cell := range notebook.Cells {
+		switch cell.Kind {
+		case editor.CodeKind:
+			if name := cell.Metadata[outputOfAttribute]; name != "" {
+				if output, ok := outputs[name]; ok {
+					cell.Value = output
+				}
+				continue
+			}
+
+			name := cell.Metadata[cellNameKey]
+			output, ok := outputs[name]
+			if _, hasAttr := cell.Metadata[expectOutputKey]; !ok || !hasAttr {
+				continue
+			}
+			if output == "" || strings.ContainsAny(output, " \t\r\n}") {
+				return nil, errors.Errorf("cannot write output of %q to expect-output; use an output block instead", name)
+			}
+			cell.Metadata[expectOutputKey] = output
+
+		case editor.MarkupKind:
+			value, err := updateOutputBlock(cell.Value, outputs)
+			if err != nil {
+				return nil, err
+			}
+			cell.Value = value
+		}
+	}
+
+	result, err := editor.Serialize(notebook)
+	return result, errors.Wrap(err, "failed to serialize")
+}
+
+// updateOutputBlock returns value with the content replaced
+// if value is a single output block of a command in outputs.
+func updateOutputBlock(value string, outputs map[string]string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+		return value, nil
+	}
+
+	node, _, err := document.New([]byte(trimmed), cmark.Render).Parse()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse output block")
+	}
+
+	blocks := document.CollectCodeBlocks(node)
+	if len(blocks) != 1 || len(node.Children()) != 1 {
+		return value, nil
+	}
+
+	output, ok := outputs[blocks[0].OutputOf()]
+	if !ok {
+		return value, nil
+	}
+
+	infoLine, _, _ := strings.Cut(trimmed, "\n")
+	info := strings.TrimLeft(infoLine, "`~")
+
+	fence := strings.Repeat("`", longestBacktickSeq(output)+1)
+	if len(fence) < 3 {
+		fence = "```"
+	}
+
+	return fence + info + "\n" + output + "\n" + fence, nil
+}
+
+func longestBacktickSeq(s string) int {
+	longest, current := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
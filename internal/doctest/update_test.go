@@ -0,0 +1,41 @@
+package doctest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate(t *testing.T) {
+	source := "# Example\n\n" +
+		"```sh { name=greet }\necho hello\n```\n\n" +
+		"```text { output-of=greet }\nhi\n```\n\n" +
+		"```sh { name=version expect-output=v1 }\necho v2\n```\n\n" +
+		"```sh { name=other }\necho other\n```\n\n" +
+		"```text { output-of=other }\nother\n```\n"
+
+	result, err := Update([]byte(source), map[string]string{
+		"greet":   "hello\n```\nworld",
+		"version": "v2",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		"# Example\n\n"+
+			"```sh { name=greet }\necho hello\n```\n\n"+
+			"````text { output-of=greet }\nhello\n```\nworld\n````\n\n"+
+			"```sh { name=version expect-output=v2 }\necho v2\n```\n\n"+
+			"```sh { name=other }\necho other\n```\n\n"+
+			"```text { output-of=other }\nother\n```\n",
+		string(result),
+	)
+}
+
+func TestUpdate_ExpectOutputWithSpaces(t *testing.T) {
+	source := "```sh { name=greet expect-output=hi }\necho hello world\n```\n"
+
+	_, err := Update([]byte(source), map[string]string{"greet": "hello world"})
+	assert.EqualError(t, err, `cannot write output of "greet" to expect-output; use an output block instead`)
+}
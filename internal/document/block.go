@@ -2,6 +2,7 @@ package document
 
 import (
 	"bytes"
+	"math"
 	"regexp"
 	"strings"
 
@@ -51,6 +52,7 @@ type CodeBlock struct {
 	language   string
 	lines      []string
 	name       string
+	sections   []string
 	value      []byte
 }
 
@@ -75,6 +77,7 @@ func newCodeBlock(
 		language:   getLanguage(node, source),
 		lines:      getLines(node, source),
 		name:       name,
+		sections:   getSections(node, source),
 		value:      value,
 	}, nil
 }
@@ -108,6 +111,26 @@ func (b *CodeBlock) Name() string {
 	return b.name
 }
 
+// Section returns the text of the closest heading preceding the block.
+func (b *CodeBlock) Section() string {
+	if len(b.sections) == 0 {
+		return ""
+	}
+	return b.sections[0]
+}
+
+// InSection returns true if the block is placed in the section
+// with the given heading, including its subsections.
+// The comparison is case-insensitive.
+func (b *CodeBlock) InSection(heading string) bool {
+	for _, s := range b.sections {
+		if strings.EqualFold(s, heading) {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *CodeBlock) Unwrap() ast.Node {
 	return b.inner
 }
@@ -148,7 +171,7 @@ func parseRawAttributes(source []byte) map[string]string {
 		if !bytes.Contains(item, []byte{'='}) {
 			continue
 		}
-		kv := bytes.Split(item, []byte{'='})
+		kv := bytes.SplitN(item, []byte{'='}, 2)
 		result[string(kv[0])] = string(kv[1])
 	}
 
@@ -183,15 +206,35 @@ func getIntro(node *ast.FencedCodeBlock, source []byte) string {
 	return ""
 }
 
+// getSections returns texts of headings enclosing the node
+// starting from the closest one.
+func getSections(node ast.Node, source []byte) (result []string) {
+	level := math.MaxInt
+	for n := node; n != nil && level > 1; n = n.Parent() {
+		for prev := n.PreviousSibling(); prev != nil; prev = prev.PreviousSibling() {
+			heading, ok := prev.(*ast.Heading)
+			if !ok || heading.Level >= level {
+				continue
+			}
+			level = heading.Level
+			result = append(result, string(heading.Text(source)))
+		}
+	}
+	return result
+}
+
 func normalizeLine(s string) string {
 	return strings.TrimSpace(strings.TrimLeft(s, "$"))
 }
 
+// getLines returns lines of the block as written. Only a leading "$ "
+// prompt, like in "$ brew update", is removed.
 func getLines(node *ast.FencedCodeBlock, source []byte) []string {
 	var result []string
 	for i := 0; i < node.Lines().Len(); i++ {
 		line := node.Lines().At(i)
-		result = append(result, normalizeLine(string(line.Value(source))))
+		value := strings.TrimSuffix(strings.TrimSuffix(string(line.Value(source)), "\n"), "\r")
+		result = append(result, strings.TrimPrefix(value, "$ "))
 	}
 	return result
 }
@@ -238,7 +281,7 @@ func getName(node *ast.FencedCodeBlock, source []byte, nameResolver *nameResolve
 	} else {
 		lines := getLines(node, source)
 		if len(lines) > 0 {
-			name = sanitizeName(lines[0])
+			name = sanitizeName(normalizeLine(lines[0]))
 		}
 	}
 	return nameResolver.Get(node, name)
@@ -0,0 +1,46 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeBlock_Sections(t *testing.T) {
+	blocks := parseCodeBlocks(t, "```sh\necho intro\n```\n\n"+
+		"# Setup\n\n```sh\necho setup\n```\n\n"+
+		"## Database\n\n1. Start it:\n\n   ```sh\n   echo db\n   ```\n\n"+
+		"# Usage\n\n```sh\necho usage\n```\n")
+
+	assert.Equal(t, "", blocks[0].Section())
+	assert.False(t, blocks[0].InSection("setup"))
+
+	assert.Equal(t, "Setup", blocks[1].Section())
+	assert.True(t, blocks[1].InSection("setup"))
+
+	assert.Equal(t, "Database", blocks[2].Section())
+	assert.True(t, blocks[2].InSection("Database"))
+	assert.True(t, blocks[2].InSection("Setup"))
+	assert.False(t, blocks[2].InSection("Usage"))
+
+	assert.Equal(t, "Usage", blocks[3].Section())
+	assert.False(t, blocks[3].InSection("Setup"))
+}
+
+func TestCodeBlock_Lines(t *testing.T) {
+	blocks := parseCodeBlocks(t, "```sh\n"+
+		"$ cd $PWD/..\n"+
+		"cat <<-EOF \n"+
+		"\t  indented  \n"+
+		"\tEOF\n"+
+		"\n"+
+		"echo $HOME\n"+
+		"```\n")
+
+	assert.Equal(
+		t,
+		[]string{"cd $PWD/..", "cat <<-EOF ", "\t  indented  ", "\tEOF", "", "echo $HOME"},
+		blocks[0].Lines(),
+	)
+	assert.Equal(t, "cd-pwd", blocks[0].Name())
+}
@@ -0,0 +1,73 @@
+package document
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Needs returns names of blocks listed in the "needs" attribute
+// which must be executed before this block.
+func (b *CodeBlock) Needs() (result []string) {
+	for _, name := range strings.Split(b.attributes["needs"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// ResolveDependencies returns blocks that need to be executed
+// in order to execute the named block. The result is ordered
+// topologically and the named block is the last one.
+func (b CodeBlocks) ResolveDependencies(name string) (CodeBlocks, error) {
+	r := dependencyResolver{
+		blocks:  b,
+		visited: make(map[string]bool),
+	}
+	if err := r.visit(name, nil); err != nil {
+		return nil, err
+	}
+	return r.result, nil
+}
+
+type dependencyResolver struct {
+	blocks CodeBlocks
+	// visited is true for blocks that are resolved
+	// and false for blocks that are being resolved.
+	visited map[string]bool
+	result  CodeBlocks
+}
+
+func (r *dependencyResolver) visit(name string, path []string) error {
+	path = append(path, name)
+
+	done, ok := r.visited[name]
+	if ok && done {
+		return nil
+	}
+	if ok {
+		return errors.Errorf("dependency cycle detected: %s", strings.Join(path, " -> "))
+	}
+
+	block := r.blocks.Lookup(name)
+	if block == nil {
+		if len(path) > 1 {
+			return errors.Errorf("command %q needs unknown command %q", path[len(path)-2], name)
+		}
+		return errors.Errorf("command %q not found", name)
+	}
+
+	r.visited[name] = false
+
+	for _, dep := range block.Needs() {
+		if err := r.visit(dep, path); err != nil {
+			return err
+		}
+	}
+
+	r.visited[name] = true
+	r.result = append(r.result, block)
+
+	return nil
+}
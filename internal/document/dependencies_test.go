@@ -0,0 +1,49 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stateful/runme/internal/renderer/cmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseCodeBlocks(t *testing.T, data string) CodeBlocks {
+	t.Helper()
+	node, _, err := New([]byte(data), cmark.Render).Parse()
+	require.NoError(t, err)
+	return CollectCodeBlocks(node)
+}
+
+func TestCodeBlocks_ResolveDependencies(t *testing.T) {
+	blocks := parseCodeBlocks(t, "```sh { name=deps }\nnpm ci\n```\n\n"+
+		"```sh { name=db-up needs=deps }\ndocker compose up -d\n```\n\n"+
+		"```sh { name=migrate needs=db-up,deps }\nnpm run migrate\n```\n\n"+
+		"```sh { name=unrelated }\necho 1\n```\n")
+
+	assert.Equal(t, []string{"db-up", "deps"}, blocks.Lookup("migrate").Needs())
+
+	plan, err := blocks.ResolveDependencies("migrate")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deps", "db-up", "migrate"}, plan.Names())
+
+	plan, err = blocks.ResolveDependencies("deps")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deps"}, plan.Names())
+
+	_, err = blocks.ResolveDependencies("missing")
+	assert.EqualError(t, err, `command "missing" not found`)
+}
+
+func TestCodeBlocks_ResolveDependencies_Errors(t *testing.T) {
+	blocks := parseCodeBlocks(t, "```sh { name=a needs=b }\necho a\n```\n\n"+
+		"```sh { name=b needs=c }\necho b\n```\n\n"+
+		"```sh { name=c needs=a }\necho c\n```\n\n"+
+		"```sh { name=d needs=e }\necho d\n```\n")
+
+	_, err := blocks.ResolveDependencies("a")
+	assert.EqualError(t, err, "dependency cycle detected: a -> b -> c -> a")
+
+	_, err = blocks.ResolveDependencies("d")
+	assert.EqualError(t, err, `command "d" needs unknown command "e"`)
+}
@@ -0,0 +1,93 @@
+package document
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter contains document-wide settings defined
+// in the front matter of a markdown file.
+type Frontmatter struct {
+	// Interpreters maps a language to an interpreter
+	// which should be used to run its code blocks,
+	// for example, "python: .venv/bin/python".
+	Interpreters map[string]string `yaml:"interpreters"`
+	// Cwd is a default working directory of code blocks
+	// relative to the markdown file's directory.
+	Cwd string `yaml:"cwd"`
+	// Env contains default environment variables of code blocks.
+	Env map[string]string `yaml:"env"`
+	// EnvFile is a path to a dotenv file relative to
+	// the markdown file's directory. Variables from Env
+	// take precedence over variables from EnvFile.
+	EnvFile string `yaml:"env-file"`
+}
+
+// ParseFrontmatter parses the front matter returned by ParseSections.
+// YAML and JSON front matters are supported. Other formats, like TOML,
+// are ignored and result in an empty Frontmatter. As front matters are
+// also used by other tools, like Jekyll or Hugo, unknown properties are
+// ignored. Properties of unexpected types are skipped and reported in the
+// returned error, while the returned Frontmatter contains the valid ones.
+func ParseFrontmatter(raw []byte) (Frontmatter, error) {
+	var result Frontmatter
+
+	raw = bytes.TrimSpace(raw)
+
+	switch {
+	case len(raw) == 0:
+		return result, nil
+	case bytes.HasPrefix(raw, []byte("---")):
+		raw = bytes.TrimPrefix(raw, []byte("---"))
+		raw = bytes.TrimSuffix(raw, []byte("---"))
+	case raw[0] == '{':
+		// JSON is a subset of YAML so it can be decoded as is.
+	default:
+		return result, nil
+	}
+
+	var props map[string]yaml.Node
+	if err := yaml.Unmarshal(raw, &props); err != nil {
+		return result, errors.Wrap(err, "failed to parse front matter")
+	}
+
+	var invalid []string
+
+	decode := func(name string, value interface{}) bool {
+		node, ok := props[name]
+		if !ok {
+			return false
+		}
+		if err := node.Decode(value); err != nil {
+			invalid = append(invalid, name)
+			return false
+		}
+		return true
+	}
+
+	var (
+		interpreters, env map[string]string
+		cwd, envFile      string
+	)
+	if decode("interpreters", &interpreters) {
+		result.Interpreters = interpreters
+	}
+	if decode("cwd", &cwd) {
+		result.Cwd = cwd
+	}
+	if decode("env", &env) {
+		result.Env = env
+	}
+	if decode("env-file", &envFile) {
+		result.EnvFile = envFile
+	}
+
+	if len(invalid) > 0 {
+		return result, errors.Errorf("invalid front matter properties: %s", strings.Join(invalid, ", "))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		fmatter, err := ParseFrontmatter([]byte("---\ntitle: Example\ninterpreters:\n  python: .venv/bin/python\n---"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"python": ".venv/bin/python"}, fmatter.Interpreters)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		fmatter, err := ParseFrontmatter([]byte(`{"interpreters": {"python": "python3.11"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"python": "python3.11"}, fmatter.Interpreters)
+	})
+
+	t.Run("TOML", func(t *testing.T) {
+		fmatter, err := ParseFrontmatter([]byte("+++\ntitle = \"Example\"\n+++"))
+		require.NoError(t, err)
+		assert.Equal(t, Frontmatter{}, fmatter)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		fmatter, err := ParseFrontmatter([]byte("---\ninterpreters: [\n---"))
+		require.Error(t, err)
+		assert.Equal(t, Frontmatter{}, fmatter)
+	})
+
+	t.Run("Foreign", func(t *testing.T) {
+		fmatter, err := ParseFrontmatter([]byte("---\n" +
+			"layout: post\n" +
+			"title: Deploying\n" +
+			"tags: [ops, k8s]\n" +
+			"env: production\n" +
+			"cwd: web\n" +
+			"interpreters:\n  python: [python3]\n" +
+			"---"))
+		require.EqualError(t, err, "invalid front matter properties: interpreters, env")
+		assert.Equal(t, Frontmatter{Cwd: "web"}, fmatter)
+	})
+}
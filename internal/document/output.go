@@ -0,0 +1,8 @@
+package document
+
+// OutputOf returns the name of the block listed in the "output-of"
+// attribute. Such a block contains the expected output of the named
+// block instead of code to execute.
+func (b *CodeBlock) OutputOf() string {
+	return b.attributes["output-of"]
+}
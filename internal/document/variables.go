@@ -0,0 +1,188 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+)
+
+type VariableKind int
+
+const (
+	// PlaceholderVariable is a placeholder like <your-token>.
+	PlaceholderVariable VariableKind = iota + 1
+	// ExportVariable is an export without a value like "export TOKEN=".
+	ExportVariable
+	// ReferenceVariable is a reference like $PROJECT_ID or ${PROJECT_ID}
+	// to a variable which is not assigned in the block.
+	ReferenceVariable
+)
+
+// Variable is a value which needs to be provided
+// before a shell code block can be executed.
+type Variable struct {
+	Kind VariableKind
+	// Name is the text between angle brackets for placeholders
+	// and the variable name otherwise.
+	Name string
+}
+
+func (v Variable) String() string {
+	if v.Kind == PlaceholderVariable {
+		return "<" + v.Name + ">"
+	}
+	return v.Name
+}
+
+var (
+	placeholderRe = regexp.MustCompile(`<([A-Za-z][\w-]*)>`)
+	closingTagRe  = regexp.MustCompile(`</([A-Za-z][\w-]*)>`)
+	exportRe      = regexp.MustCompile(`(^|[\s;&|(])export\s+([A-Za-z_]\w*)=($|[\s;&|)])`)
+	assignmentRe  = regexp.MustCompile(`(?:^|[;&|(]|\b(?:export|local|declare|readonly|typeset)\s)\s*(?:-\w+\s+)*([A-Za-z_]\w*)=`)
+	loopVarRe     = regexp.MustCompile(`\b(?:for|read(?:\s+-\w+)*)\s+([A-Za-z_]\w*)`)
+	referenceRe   = regexp.MustCompile(`(?:^|[^\\$])\$(?:\{([A-Z_][A-Z0-9_]*)\}|([A-Z_][A-Z0-9_]*))`)
+)
+
+// shellVariables are set by shells and never
+// need to be provided by the user.
+var shellVariables = map[string]bool{
+	"BASHPID":  true,
+	"BASH":     true,
+	"EUID":     true,
+	"HOSTNAME": true,
+	"IFS":      true,
+	"LINENO":   true,
+	"OLDPWD":   true,
+	"OSTYPE":   true,
+	"PPID":     true,
+	"PWD":      true,
+	"RANDOM":   true,
+	"SECONDS":  true,
+	"SHLVL":    true,
+	"UID":      true,
+}
+
+// Variables returns unresolved variables in the order
+// of their first occurrence in the block. They are meaningful
+// only for shell code blocks.
+func (b *CodeBlock) Variables() []Variable {
+	var (
+		result []Variable
+		seen   = make(map[Variable]bool)
+	)
+
+	add := func(v Variable) {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+
+	assigned := make(map[string]bool)
+	for _, line := range b.lines {
+		for _, m := range assignmentRe.FindAllStringSubmatch(line, -1) {
+			assigned[m[1]] = true
+		}
+		for _, m := range loopVarRe.FindAllStringSubmatch(line, -1) {
+			assigned[m[1]] = true
+		}
+	}
+
+	closedTags := b.closedTags()
+
+	for _, line := range b.lines {
+		for _, m := range placeholderMatches(line, closedTags) {
+			add(Variable{Kind: PlaceholderVariable, Name: line[m[2]:m[3]]})
+		}
+
+		for _, m := range exportRe.FindAllStringSubmatch(line, -1) {
+			add(Variable{Kind: ExportVariable, Name: m[2]})
+		}
+
+		for _, m := range referenceRe.FindAllStringSubmatch(line, -1) {
+			name := m[1] + m[2]
+			if !assigned[name] && !shellVariables[name] && !strings.HasPrefix(name, "BASH_") {
+				add(Variable{Kind: ReferenceVariable, Name: name})
+			}
+		}
+	}
+
+	return result
+}
+
+// ResolveVariables replaces placeholders and sets exports with values
+// found in values, keyed by Variable.Name. Values of exports are quoted.
+// References are left intact as their values are expected to be
+// provided through the environment.
+func (b *CodeBlock) ResolveVariables(values map[string]string) {
+	closedTags := b.closedTags()
+
+	for idx, line := range b.lines {
+		var (
+			resolved strings.Builder
+			last     int
+		)
+		for _, m := range placeholderMatches(line, closedTags) {
+			if value, ok := values[line[m[2]:m[3]]]; ok {
+				resolved.WriteString(line[last:m[0]])
+				resolved.WriteString(value)
+				last = m[1]
+			}
+		}
+		resolved.WriteString(line[last:])
+		line = resolved.String()
+
+		line = exportRe.ReplaceAllStringFunc(line, func(match string) string {
+			m := exportRe.FindStringSubmatch(match)
+			value, ok := values[m[2]]
+			if !ok {
+				return match
+			}
+			return m[1] + "export " + m[2] + "=" + quoteShell(value) + m[3]
+		})
+
+		b.lines[idx] = line
+	}
+}
+
+// closedTags returns names of HTML tags closed in the block, like "b" in "</b>".
+func (b *CodeBlock) closedTags() map[string]bool {
+	tags := make(map[string]bool)
+	for _, line := range b.lines {
+		for _, m := range closingTagRe.FindAllStringSubmatch(line, -1) {
+			tags[strings.ToLower(m[1])] = true
+		}
+	}
+	return tags
+}
+
+// placeholderMatches returns submatch indexes of placeholders in line.
+// Matches which are shell syntax, like the heredoc in "cat <<EOF>out"
+// or the redirections in "sort <in>out", and HTML tags closed
+// in the block, like "<b>" in "<b>bold</b>", are skipped.
+func placeholderMatches(line string, closedTags map[string]bool) [][]int {
+	var result [][]int
+	for _, m := range placeholderRe.FindAllStringSubmatchIndex(line, -1) {
+		if m[0] > 0 && line[m[0]-1] == '<' {
+			continue
+		}
+		if m[1] < len(line) && isRedirectionTarget(line[m[1]]) {
+			continue
+		}
+		if closedTags[strings.ToLower(line[m[2]:m[3]])] {
+			continue
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// isRedirectionTarget reports whether c, following a match like "<in>",
+// makes it an input redirection followed by an output one.
+func isRedirectionTarget(c byte) bool {
+	return c == '_' || c == '&' || c == '>' ||
+		'0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func quoteShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
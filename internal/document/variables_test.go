@@ -0,0 +1,101 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeBlock_Variables(t *testing.T) {
+	blocks := parseCodeBlocks(t, "```sh\n"+
+		"export TOKEN=\n"+
+		"export API_URL=<api-url>\n"+
+		"export STAGE=dev; export REGION= && echo $REGION\n"+
+		"gcloud config set project $PROJECT_ID --account ${ACCOUNT}\n"+
+		"echo $TOKEN $STAGE ${HOME:-/tmp} \\$ESCAPED $RANDOM $1 $lower\n"+
+		"cat <input.txt | grep <pattern>\n"+
+		"echo $PROJECT_ID NAME=$NAME\n"+
+		"for FILE in *.md; do echo $FILE; done\n"+
+		"read -r ANSWER && echo $ANSWER\n"+
+		"```\n")
+
+	assert.Equal(
+		t,
+		[]Variable{
+			{Kind: ExportVariable, Name: "TOKEN"},
+			{Kind: PlaceholderVariable, Name: "api-url"},
+			{Kind: ExportVariable, Name: "REGION"},
+			{Kind: ReferenceVariable, Name: "PROJECT_ID"},
+			{Kind: ReferenceVariable, Name: "ACCOUNT"},
+			{Kind: PlaceholderVariable, Name: "pattern"},
+			{Kind: ReferenceVariable, Name: "NAME"},
+		},
+		blocks[0].Variables(),
+	)
+}
+
+func TestCodeBlock_Variables_NotPlaceholders(t *testing.T) {
+	blocks := parseCodeBlocks(t, "```sh\n"+
+		"echo \"<b>bold</b>\" > page.html\n"+
+		"cat <<EOF>out.txt\n"+
+		"<B>upper</b>\n"+
+		"EOF\n"+
+		"cat <<<EOF>out.txt\n"+
+		"sort <in>out && tr a-z A-Z <out>&2\n"+
+		"curl https://<host>/api > <file>.json\n"+
+		"```\n")
+
+	assert.Equal(
+		t,
+		[]Variable{
+			{Kind: PlaceholderVariable, Name: "host"},
+			{Kind: PlaceholderVariable, Name: "file"},
+		},
+		blocks[0].Variables(),
+	)
+
+	blocks[0].ResolveVariables(map[string]string{"b": "x", "EOF": "x", "in": "x", "host": "localhost", "file": "data"})
+
+	assert.Equal(
+		t,
+		[]string{
+			`echo "<b>bold</b>" > page.html`,
+			"cat <<EOF>out.txt",
+			"<B>upper</b>",
+			"EOF",
+			"cat <<<EOF>out.txt",
+			"sort <in>out && tr a-z A-Z <out>&2",
+			"curl https://localhost/api > data.json",
+		},
+		blocks[0].Lines(),
+	)
+}
+
+func TestCodeBlock_ResolveVariables(t *testing.T) {
+	blocks := parseCodeBlocks(t, "```sh\n"+
+		"export TOKEN=\n"+
+		"export API_URL=<api-url>\n"+
+		"export REGION= && echo $REGION\n"+
+		"gcloud config set project $PROJECT_ID\n"+
+		"echo <unknown>\n"+
+		"```\n")
+
+	blocks[0].ResolveVariables(map[string]string{
+		"TOKEN":      "it's secret",
+		"api-url":    "http://localhost",
+		"REGION":     "eu",
+		"PROJECT_ID": "demo",
+	})
+
+	assert.Equal(
+		t,
+		[]string{
+			`export TOKEN='it'\''s secret'`,
+			"export API_URL=http://localhost",
+			"export REGION='eu' && echo $REGION",
+			"gcloud config set project $PROJECT_ID",
+			"echo <unknown>",
+		},
+		blocks[0].Lines(),
+	)
+}
@@ -196,6 +196,15 @@ export class PostSessionRequest extends Message<PostSessionRequest> {
    */
   prompt = "";
 
+  /**
+   * record_path, if not empty, is a path to a file where
+   * the session's terminal output is recorded
+   * in the asciicast v2 format.
+   *
+   * @generated from field: string record_path = 3;
+   */
+  recordPath = "";
+
   constructor(data?: PartialMessage<PostSessionRequest>) {
     super();
     proto3.util.initPartial(data, this);
@@ -206,6 +215,7 @@ export class PostSessionRequest extends Message<PostSessionRequest> {
   static readonly fields: FieldList = proto3.util.newFieldList(() => [
     { no: 1, name: "command", kind: "scalar", T: 9 /* ScalarType.STRING */ },
     { no: 2, name: "prompt", kind: "scalar", T: 9 /* ScalarType.STRING */ },
+    { no: 3, name: "record_path", kind: "scalar", T: 9 /* ScalarType.STRING */ },
   ]);
 
   static fromBinary(bytes: Uint8Array, options?: Partial<BinaryReadOptions>): PostSessionRequest {
@@ -0,0 +1,142 @@
+// Package history keeps an append-only log of executed commands.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Entry describes a single execution of a command.
+type Entry struct {
+	// ID is the position of the entry in the history starting from 1.
+	// It's assigned when reading the history and not stored.
+	ID   int       `json:"id,omitempty"`
+	Time time.Time `json:"time"`
+	// File is an absolute path of the markdown file.
+	File string `json:"file"`
+	Name string `json:"name"`
+	// Script is the executed code after replacements.
+	Script string `json:"script"`
+	// Replace contains sed scripts passed with --replace.
+	Replace  []string      `json:"replace,omitempty"`
+	Session  string        `json:"session,omitempty"`
+	Dir      string        `json:"dir"`
+	ExitCode int           `json:"exitCode"`
+	Duration time.Duration `json:"-"`
+}
+
+type entryJSON struct {
+	entryAlias
+	// Duration is in seconds.
+	Duration float64 `json:"duration"`
+}
+
+type entryAlias Entry
+
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(entryJSON{entryAlias: entryAlias(e), Duration: e.Duration.Seconds()})
+}
+
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var v entryJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = Entry(v.entryAlias)
+	e.Duration = time.Duration(v.Duration * float64(time.Second))
+	return nil
+}
+
+// Store keeps entries in a file, one JSON object per line.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Append adds the entry to the end of the history. Each entry is
+// written with a single write to a file opened in append mode so that
+// concurrently running commands do not interleave their entries.
+func (s *Store) Append(e Entry) error {
+	e.ID = 0
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create history dir")
+	}
+
+	// Scripts might contain secrets hence the file
+	// is readable only by the owner.
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open history")
+	}
+
+	_, err = f.Write(append(data, '\n'))
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+
+	return errors.Wrap(err, "failed to write history")
+}
+
+// List returns all entries from the oldest to the newest.
+// Lines which cannot be parsed, for example, due to a write
+// interrupted by a crash, are skipped but keep their IDs.
+func (s *Store) List() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open history")
+	}
+	defer func() { _ = f.Close() }()
+
+	var (
+		result  []Entry
+		scanner = bufio.NewScanner(f)
+		id      = 0
+	)
+
+	// Scripts can be long.
+	scanner.Buffer(nil, 16<<20)
+
+	for scanner.Scan() {
+		id++
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		e.ID = id
+		result = append(result, e)
+	}
+
+	return result, errors.Wrap(scanner.Err(), "failed to read history")
+}
+
+// Get returns the entry with the given ID.
+func (s *Store) Get(id int) (Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, errors.Errorf("history entry %d not found", id)
+}
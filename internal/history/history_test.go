@@ -0,0 +1,92 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runme", "history.jsonl")
+	store := NewStore(path)
+
+	entries, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Date(2023, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.Append(Entry{
+		Time:     now,
+		File:     "/project/README.md",
+		Name:     "build",
+		Script:   "make",
+		Dir:      "/project",
+		Duration: 1500 * time.Millisecond,
+	}))
+	require.NoError(t, store.Append(Entry{
+		ID:       10,
+		Time:     now.Add(time.Minute),
+		File:     "/project/README.md",
+		Name:     "deploy",
+		Script:   "deploy prod",
+		Replace:  []string{"s/staging/prod/"},
+		Dir:      "/project",
+		ExitCode: 1,
+	}))
+
+	entries, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 1, entries[0].ID)
+	assert.Equal(t, "build", entries[0].Name)
+	assert.Equal(t, 1500*time.Millisecond, entries[0].Duration)
+	assert.True(t, now.Equal(entries[0].Time))
+	assert.Equal(t, 2, entries[1].ID)
+	assert.Equal(t, []string{"s/staging/prod/"}, entries[1].Replace)
+	assert.Equal(t, 1, entries[1].ExitCode)
+
+	e, err := store.Get(2)
+	require.NoError(t, err)
+	assert.Equal(t, "deploy", e.Name)
+
+	_, err = store.Get(3)
+	assert.EqualError(t, err, "history entry 3 not found")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestStore_CorruptedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := NewStore(path)
+
+	require.NoError(t, store.Append(Entry{Name: "first"}))
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("{\"name\": \"trunc\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, store.Append(Entry{Name: "third"}))
+
+	entries, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 3, entries[1].ID)
+	assert.Equal(t, "third", entries[1].Name)
+}
+
+func TestEntry_JSON(t *testing.T) {
+	data, err := json.Marshal(Entry{ID: 1, Name: "build", Duration: 2 * time.Second})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id":1`)
+	assert.Contains(t, string(data), `"duration":2`)
+}
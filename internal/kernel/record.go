@@ -0,0 +1,74 @@
+package kernel
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/asciicast"
+	xpty "github.com/stateful/runme/internal/pty"
+)
+
+// recording writes the session's terminal output
+// to a file in the asciicast v2 format.
+type recording struct {
+	*asciicast.Writer
+
+	f        *os.File
+	close    sync.Once
+	closeErr error
+}
+
+func newRecording(path, command string) (*recording, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create recording")
+	}
+
+	w, err := asciicast.NewWriter(f, asciicast.Header{
+		Width:   xpty.DefaultCols,
+		Height:  xpty.DefaultRows,
+		Command: command,
+	})
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &recording{Writer: w, f: f}, nil
+}
+
+// Close flushes the recording and closes the file.
+// Subsequent calls return the result of the first one.
+func (r *recording) Close() error {
+	r.close.Do(func() {
+		r.closeErr = r.Writer.Close()
+		if err := r.f.Close(); r.closeErr == nil && err != nil {
+			r.closeErr = errors.Wrap(err, "failed to close recording")
+		}
+	})
+	return r.closeErr
+}
+
+// multiWriteCloser duplicates writes to all its writers
+// like io.MultiWriter and closes all of them on Close.
+type multiWriteCloser []io.WriteCloser
+
+func (m multiWriteCloser) Write(p []byte) (int, error) {
+	for _, w := range m {
+		if _, err := w.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (m multiWriteCloser) Close() (err error) {
+	for _, w := range m {
+		if cErr := w.Close(); err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
@@ -8,18 +8,22 @@ import (
 
 	"github.com/pkg/errors"
 	kernelv1 "github.com/stateful/runme/internal/gen/proto/go/runme/kernel/v1"
+	"github.com/stateful/runme/internal/redact"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
-func NewKernelServiceServer(logger *zap.Logger) kernelv1.KernelServiceServer {
-	return newKernelServiceServer(logger)
+// NewKernelServiceServer creates a kernel service. If redactor is not nil,
+// secrets are masked in the output of sessions.
+func NewKernelServiceServer(logger *zap.Logger, redactor *redact.Redactor) kernelv1.KernelServiceServer {
+	return newKernelServiceServer(logger, redactor)
 }
 
-func newKernelServiceServer(logger *zap.Logger) *kernelServiceServer {
+func newKernelServiceServer(logger *zap.Logger, redactor *redact.Redactor) *kernelServiceServer {
 	return &kernelServiceServer{
 		sessions: &sessionsContainer{},
+		redactor: redactor,
 		logger:   logger,
 	}
 }
@@ -28,6 +32,7 @@ type kernelServiceServer struct {
 	kernelv1.UnimplementedKernelServiceServer
 
 	sessions *sessionsContainer
+	redactor *redact.Redactor
 	logger   *zap.Logger
 }
 
@@ -41,7 +46,7 @@ func (s *kernelServiceServer) PostSession(ctx context.Context, req *kernelv1.Pos
 		}
 	}
 
-	session, data, err := newSession(req.Command, promptStr, s.logger)
+	session, data, err := newSession(req.Command, promptStr, req.RecordPath, s.redactor, s.logger)
 	if err != nil {
 		return nil, err
 	}
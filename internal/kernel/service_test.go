@@ -26,7 +26,7 @@ func testStartKernelServiceServer(t *testing.T) (
 ) {
 	lis := bufconn.Listen(2048)
 	server := grpc.NewServer()
-	kernelv1.RegisterKernelServiceServer(server, NewKernelServiceServer(zap.NewNop()))
+	kernelv1.RegisterKernelServiceServer(server, NewKernelServiceServer(zap.NewNop(), nil))
 	go server.Serve(lis)
 	return lis, server.Stop
 }
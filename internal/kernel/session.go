@@ -18,15 +18,19 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/xid"
 	"github.com/stateful/runme/expect"
+	xpty "github.com/stateful/runme/internal/pty"
+	"github.com/stateful/runme/internal/redact"
 	"go.uber.org/zap"
 )
 
 type limitedBuffer struct {
-	buf    *ringBuffer
-	mu     sync.Mutex
-	state  *atomic.Uint32
-	more   chan struct{}
-	closed chan struct{}
+	buf *ringBuffer
+	// redactor, if not nil, masks secrets in data written to buf.
+	redactor *redact.Writer
+	mu       sync.Mutex
+	state    *atomic.Uint32
+	more     chan struct{}
+	closed   chan struct{}
 }
 
 func (b *limitedBuffer) Close() error {
@@ -52,17 +56,40 @@ func (b *limitedBuffer) Read(p []byte) (int, error) {
 
 func (b *limitedBuffer) Write(p []byte) (n int, err error) {
 	b.mu.Lock()
-	n, err = b.buf.Write(p)
+	if b.redactor != nil {
+		n, err = b.redactor.Write(p)
+	} else {
+		n, err = b.buf.Write(p)
+	}
+	b.mu.Unlock()
+	b.notify()
+	return
+}
+
+// Flush writes data held back by the redactor.
+func (b *limitedBuffer) Flush() error {
+	if b.redactor == nil {
+		return nil
+	}
+	b.mu.Lock()
+	err := b.redactor.Flush()
 	b.mu.Unlock()
+	b.notify()
+	return err
+}
+
+func (b *limitedBuffer) notify() {
 	select {
 	case b.more <- struct{}{}:
 	default:
 	}
-	return
 }
 
 func (b *limitedBuffer) Reset() {
 	b.buf.Reset()
+	if b.redactor != nil {
+		b.redactor.Reset()
+	}
 }
 
 type session struct {
@@ -72,6 +99,8 @@ type session struct {
 	ptmx      *os.File
 	expctr    expect.Expecter
 	output    *limitedBuffer
+	recording io.WriteCloser
+	redactor  *redact.Redactor
 	execGuard chan struct{}
 	done      chan struct{}
 	mx        sync.RWMutex
@@ -79,7 +108,10 @@ type session struct {
 	logger    *zap.Logger
 }
 
-func newSession(command, prompt string, logger *zap.Logger) (*session, []byte, error) {
+// newSession spawns command in a pseudo-terminal. If recordPath is not empty,
+// the terminal's output is recorded to it in the asciicast v2 format.
+// If redactor is not nil, secrets are masked in the output and the recording.
+func newSession(command, prompt, recordPath string, redactor *redact.Redactor, logger *zap.Logger) (*session, []byte, error) {
 	promptRe, err := compileLiteralRegexp(prompt)
 	if err != nil {
 		return nil, nil, errors.WithStack(err)
@@ -91,19 +123,45 @@ func newSession(command, prompt string, logger *zap.Logger) (*session, []byte, e
 		more:   make(chan struct{}),
 		closed: make(chan struct{}),
 	}
+	if redactor != nil {
+		buf.redactor = redact.NewWriter(buf.buf, redactor)
+	}
+
+	var (
+		tee expect.Option = expect.Tee(buf)
+		rec io.WriteCloser
+	)
+	if recordPath != "" {
+		rec, err = newRecording(recordPath, command)
+		if err != nil {
+			return nil, nil, err
+		}
+		if redactor != nil {
+			rec = redact.NewWriter(rec, redactor)
+		}
+		tee = expect.Tee(multiWriteCloser{buf, rec})
+	}
 
 	_, ptmx, expctr, cmdErr, err := spawnPty(
 		command,
 		-1,
-		expect.Tee(buf),
+		tee,
 		expect.Verbose(true),
 		expect.CheckDuration(time.Millisecond*300),
 		expect.PartialMatch(true),
 	)
 	if err != nil {
+		if rec != nil {
+			_ = rec.Close()
+		}
 		return nil, nil, errors.WithStack(err)
 	}
 
+	if rec != nil {
+		// Make the terminal size match the recording.
+		_ = pty.Setsize(ptmx, &pty.Winsize{Cols: xpty.DefaultCols, Rows: xpty.DefaultRows})
+	}
+
 	s := &session{
 		id:        xid.New().String(),
 		prompt:    prompt,
@@ -111,6 +169,8 @@ func newSession(command, prompt string, logger *zap.Logger) (*session, []byte, e
 		ptmx:      ptmx,
 		expctr:    expctr,
 		output:    buf,
+		recording: rec,
+		redactor:  redactor,
 		execGuard: make(chan struct{}, 1),
 		done:      make(chan struct{}),
 		logger:    logger,
@@ -120,6 +180,7 @@ func newSession(command, prompt string, logger *zap.Logger) (*session, []byte, e
 	if err != nil {
 		return nil, nil, errors.WithStack(err)
 	}
+	data = s.redact(data)
 
 	// Bash 5.1+ has this enabled by default and it makes it harder to parse the output.
 	// We disable it here so that the output is consistent between old and new bash versions.
@@ -180,6 +241,14 @@ func (s *session) setErr(err error) {
 	s.mx.Unlock()
 }
 
+// redact masks secrets in data if the session has a redactor.
+func (s *session) redact(data []byte) []byte {
+	if s.redactor == nil {
+		return data
+	}
+	return s.redactor.Redact(data)
+}
+
 func (s *session) ID() string {
 	return s.id
 }
@@ -189,6 +258,11 @@ func (s *session) Close() error {
 		return errors.WithStack(err)
 	}
 	<-s.done
+	if s.recording != nil {
+		if err := s.recording.Close(); err != nil && s.err == nil {
+			return err
+		}
+	}
 	return s.err
 }
 
@@ -408,7 +482,7 @@ func (s *session) execute(command string, timeout time.Duration) ([]byte, error)
 
 	s.logger.Info("cleaned matched data", zap.ByteString("data", data))
 
-	return data, nil
+	return s.redact(data), nil
 }
 
 func (s *session) executeWithChannel(command string, timeout time.Duration, chunks chan<- []byte) error {
@@ -457,6 +531,7 @@ func (s *session) executeWithChannel(command string, timeout time.Duration, chun
 		return err
 	}
 
+	_ = s.output.Flush()
 	_ = reader.Close()
 
 	select {
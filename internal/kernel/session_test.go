@@ -3,15 +3,22 @@
 package kernel
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stateful/runme/internal/asciicast"
+	xpty "github.com/stateful/runme/internal/pty"
+	"github.com/stateful/runme/internal/redact"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -30,7 +37,7 @@ func testCreateSession(t *testing.T, logger *zap.Logger) (*session, string) {
 		logger = zap.NewNop()
 	}
 	bashBin, prompt := testGetBash(t)
-	sess, _, err := newSession(bashBin, string(prompt), logger)
+	sess, _, err := newSession(bashBin, string(prompt), "", nil, logger)
 	require.NoError(t, err)
 	return sess, string(prompt)
 }
@@ -47,6 +54,60 @@ func Test_session_Basic(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func Test_session_Record(t *testing.T) {
+	bashBin, prompt := testGetBash(t)
+	recordPath := filepath.Join(t.TempDir(), "session.cast")
+
+	sess, _, err := newSession(bashBin, prompt, recordPath, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	_, exitCode, err := sess.Execute("echo Hello", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+
+	err = sess.Close()
+	require.NoError(t, err)
+
+	f, err := os.Open(recordPath)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	header, err := asciicast.ReadHeader(bufio.NewReader(f))
+	require.NoError(t, err)
+	assert.Equal(t, xpty.DefaultCols, header.Width)
+	assert.Equal(t, bashBin, header.Command)
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	err = asciicast.Play(context.Background(), f, &output, asciicast.PlayOptions{MaxIdle: time.Millisecond})
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), "echo Hello\r\nHello\r\n")
+}
+
+func Test_limitedBuffer_Redact(t *testing.T) {
+	ring := newRingBuffer(1024)
+	buf := &limitedBuffer{
+		buf:      ring,
+		redactor: redact.NewWriter(ring, redact.New("s3cr3t")),
+		state:    new(atomic.Uint32),
+		more:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+
+	_, err := buf.Write([]byte("password: s3c"))
+	require.NoError(t, err)
+	_, err = buf.Write([]byte("r3t\r\ntoken: s3"))
+	require.NoError(t, err)
+	require.NoError(t, buf.Flush())
+	require.NoError(t, buf.Close())
+
+	data, err := io.ReadAll(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "password: ********\r\ntoken: s3", string(data))
+}
+
 func Test_session_Multiline(t *testing.T) {
 	sess, _ := testCreateSession(t, nil)
 
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	v1 "github.com/stateful/runme/internal/gen/proto/go/runme/kernel/v1"
 	"github.com/stateful/runme/internal/gen/proto/go/runme/kernel/v1/kernelv1connect"
+	"github.com/stateful/runme/internal/redact"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -16,9 +17,9 @@ type kernelServiceHandler struct {
 	server *kernelServiceServer
 }
 
-func NewKernelServiceHandler(logger *zap.Logger) kernelv1connect.KernelServiceHandler {
+func NewKernelServiceHandler(logger *zap.Logger, redactor *redact.Redactor) kernelv1connect.KernelServiceHandler {
 	return &kernelServiceHandler{
-		server: newKernelServiceServer(logger),
+		server: newKernelServiceServer(logger, redactor),
 	}
 }
 
@@ -21,7 +21,7 @@ func Test_kernelServiceHandler_IO(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.Handle(
 		kernelv1connect.NewKernelServiceHandler(
-			NewKernelServiceHandler(zap.NewNop()),
+			NewKernelServiceHandler(zap.NewNop(), nil),
 		),
 	)
 	server := httptest.NewUnstartedServer(mux)
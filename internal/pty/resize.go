@@ -12,6 +12,13 @@ import (
 	"github.com/creack/pty"
 )
 
+// DefaultCols and DefaultRows are the size of a terminal
+// used when the size cannot be inherited.
+const (
+	DefaultCols = 80
+	DefaultRows = 24
+)
+
 type CancelFn func()
 
 func ResizeOnSig(tty *os.File) CancelFn {
@@ -27,3 +34,13 @@ func ResizeOnSig(tty *os.File) CancelFn {
 	ch <- syscall.SIGWINCH                       // Initial resize.
 	return func() { signal.Stop(ch); close(ch) } // Cleanup signals when done.
 }
+
+// Size returns the size of the terminal f or
+// the default size if f is not a terminal.
+func Size(f *os.File) (cols, rows int) {
+	size, err := pty.GetsizeFull(f)
+	if err != nil || size.Cols == 0 || size.Rows == 0 {
+		return DefaultCols, DefaultRows
+	}
+	return int(size.Cols), int(size.Rows)
+}
@@ -5,8 +5,21 @@ package pty
 
 import "os"
 
+// DefaultCols and DefaultRows are the size of a terminal
+// used when the size cannot be inherited.
+const (
+	DefaultCols = 80
+	DefaultRows = 24
+)
+
 type CancelFn func()
 
 func ResizeOnSig(tty *os.File) CancelFn {
 	return func() {}
 }
+
+// Size returns the default size as pseudo-terminals
+// are not supported on Windows.
+func Size(f *os.File) (cols, rows int) {
+	return DefaultCols, DefaultRows
+}
@@ -0,0 +1,342 @@
+// Package redact masks secrets, like tokens and passwords, in output
+// of commands. Secrets are known values, for example, taken from
+// environment variables, and well-known token formats.
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Mask replaces secrets in the output.
+const Mask = "********"
+
+// minSecretLen is the minimum length of a secret value. Shorter values,
+// like "1" or "yes", would mask unrelated output.
+const minSecretLen = 4
+
+// maxTokenLen limits how much output is held back by Writer
+// waiting for the rest of a potential token.
+const maxTokenLen = 8192
+
+// DefaultEnvPatterns match names of environment variables
+// whose values are secrets.
+var DefaultEnvPatterns = []string{
+	"*_TOKEN",
+	"*_SECRET",
+	"*_SECRET_*",
+	"*_PASSWORD",
+	"*_API_KEY",
+	"*_ACCESS_KEY",
+	"*_PRIVATE_KEY",
+}
+
+// tokenFormat describes a well-known format of tokens.
+type tokenFormat struct {
+	// prefixes start every token.
+	prefixes []string
+	// isTokenByte reports whether b can occur in a token.
+	isTokenByte func(b byte) bool
+	re          *regexp.Regexp
+}
+
+func isAlnum(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+var tokenFormats = []tokenFormat{
+	{
+		// AWS access key IDs.
+		prefixes:    []string{"AKIA", "ASIA", "AGPA", "AIDA", "AROA", "ANPA", "ANVA", "AIPA"},
+		isTokenByte: isAlnum,
+		re:          regexp.MustCompile(`\b(AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b`),
+	},
+	{
+		// GitHub tokens.
+		prefixes:    []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_"},
+		isTokenByte: func(b byte) bool { return isAlnum(b) || b == '_' },
+		re:          regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`),
+	},
+	{
+		// Slack tokens.
+		prefixes:    []string{"xoxa-", "xoxb-", "xoxp-", "xoxo-", "xoxr-", "xoxs-"},
+		isTokenByte: func(b byte) bool { return isAlnum(b) || b == '-' },
+		re:          regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+	},
+	{
+		// JSON Web Tokens. The header and the payload are encoded JSON objects.
+		prefixes:    []string{"eyJ"},
+		isTokenByte: func(b byte) bool { return isAlnum(b) || b == '-' || b == '_' || b == '.' },
+		re:          regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	},
+}
+
+// Redactor masks secret values and tokens.
+type Redactor struct {
+	secrets [][]byte
+}
+
+// New creates a redactor masking the secret values in addition to tokens.
+func New(secrets ...string) *Redactor {
+	r := &Redactor{}
+
+	seen := make(map[string]bool)
+	for _, s := range secrets {
+		if len(strings.TrimSpace(s)) < minSecretLen || seen[s] {
+			continue
+		}
+		seen[s] = true
+		r.secrets = append(r.secrets, []byte(s))
+	}
+
+	// Longer secrets are masked first in case one contains another.
+	sort.SliceStable(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+
+	return r
+}
+
+// FromEnv returns values of environment variables, given as KEY=VALUE,
+// whose names match any of the glob patterns. Names are matched
+// case-insensitively.
+func FromEnv(env []string, patterns []string) []string {
+	var result []string
+	for _, kv := range env {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || value == "" {
+			continue
+		}
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(name)); ok {
+				result = append(result, value)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// ReadFile returns secret values listed in a file, one per line.
+// Empty lines and lines starting with "#" are skipped.
+func ReadFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open secrets file")
+	}
+	defer func() { _ = f.Close() }()
+
+	var result []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result = append(result, line)
+	}
+
+	return result, errors.Wrap(scanner.Err(), "failed to read secrets file")
+}
+
+// Redact returns data with secrets masked.
+func (r *Redactor) Redact(data []byte) []byte {
+	out, _ := r.redact(data, true)
+	return out
+}
+
+// span is a range of data to mask.
+type span struct{ start, end int }
+
+// redact masks secrets in data. Unless final is true, a suffix of data
+// which might be the beginning of a secret is not processed and returned
+// as rest to be prepended to the next data.
+func (r *Redactor) redact(data []byte, final bool) (out, rest []byte) {
+	var spans []span
+
+	for _, s := range r.secrets {
+		for offset := 0; offset < len(data); {
+			idx := bytes.Index(data[offset:], s)
+			if idx == -1 {
+				break
+			}
+			spans = append(spans, span{offset + idx, offset + idx + len(s)})
+			offset += idx + len(s)
+		}
+	}
+
+	for _, f := range tokenFormats {
+		for _, loc := range f.re.FindAllIndex(data, -1) {
+			spans = append(spans, span{loc[0], loc[1]})
+		}
+	}
+
+	spans = mergeSpans(spans)
+
+	cut := len(data)
+	if !final {
+		cut -= r.holdBack(data)
+		for _, s := range spans {
+			if s.start < cut && cut < s.end {
+				cut = s.start
+				break
+			}
+		}
+	}
+
+	out = make([]byte, 0, cut)
+	prev := 0
+	for _, s := range spans {
+		if s.start >= cut {
+			break
+		}
+		out = append(out, data[prev:s.start]...)
+		out = append(out, Mask...)
+		prev = s.end
+	}
+	out = append(out, data[prev:cut]...)
+
+	return out, data[cut:]
+}
+
+// holdBack returns the length of the longest suffix of data
+// which might be the beginning of a secret or a token.
+func (r *Redactor) holdBack(data []byte) int {
+	n := 0
+
+	for _, s := range r.secrets {
+		if k := prefixSuffixLen(data, s); k > n {
+			n = k
+		}
+	}
+
+	for _, f := range tokenFormats {
+		// A token which might not have ended yet is within
+		// the trailing run of token bytes.
+		start := len(data)
+		for start > 0 && len(data)-start < maxTokenLen && f.isTokenByte(data[start-1]) {
+			start--
+		}
+		run := data[start:]
+
+		for _, prefix := range f.prefixes {
+			if k := prefixSuffixLen(data, []byte(prefix)); k > n {
+				n = k
+			}
+			if idx := bytes.Index(run, []byte(prefix)); idx != -1 && len(run)-idx > n {
+				n = len(run) - idx
+			}
+		}
+	}
+
+	return n
+}
+
+// prefixSuffixLen returns the length of the longest proper
+// prefix of s which is a suffix of data.
+func prefixSuffixLen(data, s []byte) int {
+	for k := len(s) - 1; k > 0; k-- {
+		if bytes.HasSuffix(data, s[:k]) {
+			return k
+		}
+	}
+	return 0
+}
+
+func mergeSpans(spans []span) []span {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	result := spans[:1]
+	for _, s := range spans[1:] {
+		last := &result[len(result)-1]
+		if s.start <= last.end {
+			if s.end > last.end {
+				last.end = s.end
+			}
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// Writer masks secrets in data written to the underlying writer.
+// A secret split across writes is masked too, hence data which might
+// be the beginning of a secret is held back until the next write
+// or Flush.
+type Writer struct {
+	w io.Writer
+	r *Redactor
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+// NewWriter creates a writer masking secrets found by r.
+// Flush or Close must be called after the last write.
+func NewWriter(w io.Writer, r *Redactor) *Writer {
+	return &Writer{w: w, r: r}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out, rest := w.r.redact(append(w.pending, p...), false)
+	w.pending = append([]byte(nil), rest...)
+
+	if len(out) > 0 {
+		if _, err := w.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes data held back by the last write.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	out := w.r.Redact(w.pending)
+	w.pending = nil
+
+	_, err := w.w.Write(out)
+	return err
+}
+
+// Reset discards data held back by the last write.
+func (w *Writer) Reset() {
+	w.mu.Lock()
+	w.pending = nil
+	w.mu.Unlock()
+}
+
+// Close flushes the writer and closes the underlying
+// writer if it implements io.Closer.
+func (w *Writer) Close() error {
+	err := w.Flush()
+	if c, ok := w.w.(io.Closer); ok {
+		if cErr := c.Close(); err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+// Package report writes results of executed code blocks
+// in formats understood by CI systems.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusError   Status = "error"
+	StatusSkipped Status = "skipped"
+)
+
+// TestCase is an executed code block.
+type TestCase struct {
+	Name     string        `json:"name"`
+	File     string        `json:"file"`
+	Status   Status        `json:"status"`
+	ExitCode int           `json:"exitCode"`
+	Duration time.Duration `json:"-"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Message  string        `json:"message,omitempty"`
+}
+
+// Report contains test cases from a single markdown file.
+type Report struct {
+	File      string
+	Timestamp time.Time
+	Cases     []TestCase
+}
+
+func (r *Report) count(status Status) (n int) {
+	for _, c := range r.Cases {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Report) duration() (d time.Duration) {
+	for _, c := range r.Cases {
+		d += c.Duration
+	}
+	return d
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the report as JUnit XML.
+// Each markdown file is a test suite.
+func WriteJUnit(w io.Writer, r *Report) error {
+	suite := junitTestSuite{
+		Name:      r.File,
+		Tests:     len(r.Cases),
+		Failures:  r.count(StatusFailed),
+		Errors:    r.count(StatusError),
+		Skipped:   r.count(StatusSkipped),
+		Time:      seconds(r.duration()),
+		Timestamp: r.Timestamp.Format(time.RFC3339),
+	}
+
+	for _, c := range r.Cases {
+		tc := junitTestCase{
+			Name:      c.Name,
+			Classname: c.File,
+			File:      c.File,
+			Time:      seconds(c.Duration),
+			SystemOut: c.Stdout,
+			SystemErr: c.Stderr,
+		}
+
+		msg := &junitMessage{Message: c.Message}
+		switch c.Status {
+		case StatusFailed:
+			msg.Type = fmt.Sprintf("exit code %d", c.ExitCode)
+			tc.Failure = msg
+		case StatusError:
+			tc.Error = msg
+		case StatusSkipped:
+			tc.Skipped = msg
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	suites := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.WithStack(err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return errors.Wrap(err, "failed to encode JUnit report")
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
+
+type jsonReport struct {
+	File      string         `json:"file"`
+	Timestamp time.Time      `json:"timestamp"`
+	Duration  float64        `json:"duration"`
+	Tests     []jsonTestCase `json:"tests"`
+}
+
+type jsonTestCase struct {
+	TestCase
+	// Duration is in seconds.
+	Duration float64 `json:"duration"`
+}
+
+// WriteJSON writes the report as JSON. Durations are in seconds.
+func WriteJSON(w io.Writer, r *Report) error {
+	report := jsonReport{
+		File:      r.File,
+		Timestamp: r.Timestamp,
+		Duration:  r.duration().Seconds(),
+		Tests:     make([]jsonTestCase, 0, len(r.Cases)),
+	}
+
+	for _, c := range r.Cases {
+		report.Tests = append(report.Tests, jsonTestCase{TestCase: c, Duration: c.Duration.Seconds()})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(report), "failed to encode JSON report")
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *Report {
+	return &Report{
+		File:      "README.md",
+		Timestamp: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		Cases: []TestCase{
+			{Name: "build", File: "README.md", Status: StatusPassed, Duration: 1500 * time.Millisecond, Stdout: "built\n"},
+			{Name: "test", File: "README.md", Status: StatusFailed, ExitCode: 2, Duration: 250 * time.Millisecond, Stderr: "FAIL <pkg>\n", Message: "exit status 2"},
+			{Name: "deploy", File: "README.md", Status: StatusSkipped, ExitCode: -1},
+		},
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, testReport()))
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1" errors="0" skipped="1" time="1.750">
+  <testsuite name="README.md" tests="3" failures="1" errors="0" skipped="1" time="1.750" timestamp="2023-01-02T03:04:05Z">
+    <testcase name="build" classname="README.md" file="README.md" time="1.500">
+      <system-out>built&#xA;</system-out>
+    </testcase>
+    <testcase name="test" classname="README.md" file="README.md" time="0.250">
+      <failure message="exit status 2" type="exit code 2"></failure>
+      <system-err>FAIL &lt;pkg&gt;&#xA;</system-err>
+    </testcase>
+    <testcase name="deploy" classname="README.md" file="README.md" time="0.000">
+      <skipped></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, testReport()))
+
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+
+	assert.Equal(t, "README.md", result["file"])
+	assert.Equal(t, 1.75, result["duration"])
+
+	tests := result["tests"].([]any)
+	require.Len(t, tests, 3)
+	assert.Equal(
+		t,
+		map[string]any{
+			"name":     "test",
+			"file":     "README.md",
+			"status":   "failed",
+			"exitCode": float64(2),
+			"duration": 0.25,
+			"stderr":   "FAIL <pkg>\n",
+			"message":  "exit status 2",
+		},
+		tests[1],
+	)
+}
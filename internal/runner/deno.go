@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+type Deno struct {
+	*Base
+	Source string
+}
+
+var _ Executable = (*Deno)(nil)
+
+func (d *Deno) DryRun(ctx context.Context, w io.Writer) {
+	_, err := exec.LookPath("deno")
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "failed to find %q executable: %s\n", "deno", err)
+	}
+
+	_, _ = fmt.Fprintf(w, "// deno run --allow-all main.ts in $TEMP\n\n")
+	_, _ = fmt.Fprintf(w, "%s\n", d.Source)
+}
+
+func (d *Deno) Run(ctx context.Context) (*Result, error) {
+	executable, err := exec.LookPath("deno")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find %q executable", "deno")
+	}
+
+	// Code blocks run with the same privileges as shell commands
+	// so all permissions are granted.
+	result, err := runSourceFile(ctx, d.Base, executable, []string{"run", "--allow-all"}, "main.ts", d.Source)
+	return result, errors.Wrapf(err, "failed to run command %q", "deno run main.ts")
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReadEnvFile reads variables from a dotenv file and returns
+// them as "KEY=VALUE" entries. Each line contains an assignment
+// optionally preceded by "export". Values can be quoted.
+// Empty lines and lines starting with "#" are ignored.
+func ReadEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open env file %q", path)
+	}
+	defer func() { _ = f.Close() }()
+
+	env, err := parseEnvFile(f)
+	return env, errors.Wrapf(err, "failed to parse env file %q", path)
+}
+
+func parseEnvFile(r io.Reader) ([]string, error) {
+	var result []string
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, errors.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 {
+			switch value[0] {
+			case '"':
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, errors.Errorf("line %d: invalid quoted value", lineNo)
+				}
+				value = unquoted
+			case '\'':
+				if value[len(value)-1] == '\'' {
+					value = value[1 : len(value)-1]
+				}
+			}
+		}
+
+		result = append(result, key+"="+value)
+	}
+
+	return result, errors.WithStack(scanner.Err())
+}
+
+// MergeEnv returns env extended by overrides. A variable
+// from overrides replaces the variable with the same name in env.
+func MergeEnv(env []string, overrides ...string) []string {
+	indexes := make(map[string]int, len(env))
+	result := make([]string, 0, len(env)+len(overrides))
+
+	for _, kv := range append(env[:len(env):len(env)], overrides...) {
+		key, _, _ := strings.Cut(kv, "=")
+		if idx, ok := indexes[key]; ok {
+			result[idx] = kv
+			continue
+		}
+		indexes[key] = len(result)
+		result = append(result, kv)
+	}
+
+	return result
+}
@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	env, err := parseEnvFile(strings.NewReader(`
+# comment
+API_URL=http://localhost:8080
+export TOKEN = secret
+QUOTED="hello \"world\""
+SINGLE='a "b" c'
+EMPTY=
+`))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{
+			"API_URL=http://localhost:8080",
+			"TOKEN=secret",
+			`QUOTED=hello "world"`,
+			`SINGLE=a "b" c`,
+			"EMPTY=",
+		},
+		env,
+	)
+
+	_, err = parseEnvFile(strings.NewReader("INVALID"))
+	assert.EqualError(t, err, "line 1: expected KEY=VALUE")
+}
+
+func TestMergeEnv(t *testing.T) {
+	env := []string{"A=1", "B=2"}
+	assert.Equal(t, []string{"A=3", "B=2", "C=4"}, MergeEnv(env, "C=4", "A=3"))
+	assert.Equal(t, []string{"A=1", "B=2"}, env)
+}
@@ -3,40 +3,263 @@ package runner
 import (
 	"context"
 	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
 
 	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/redact"
+	"github.com/stateful/runme/internal/sandbox"
 )
 
 type Executable interface {
 	DryRun(context.Context, io.Writer)
-	Run(context.Context) error
+	// Run executes the command and returns its result. The result is nil
+	// if the command could not be started. A non-nil error is also
+	// returned when the command fails or is terminated by a signal.
+	Run(context.Context) (*Result, error)
 }
 
 type Base struct {
-	Dir    string
+	Dir string
+	// Env is the environment of executed commands.
+	// If nil, the current process's environment is used.
+	Env    []string
 	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
 	Name   string
+	// Session, if not nil, is updated by shell executables
+	// with the working directory and environment
+	// left by the executed commands.
+	Session *Session
+	// GracePeriod is the time given to a command to exit after
+	// it was signaled before it's killed. If zero,
+	// DefaultGracePeriod is used.
+	GracePeriod time.Duration
+	// TailSize, if positive, is the number of trailing bytes
+	// of stdout and stderr kept in the result. The output is
+	// still written to Stdout and Stderr but through pipes.
+	TailSize int
+	// Terminal, if not nil, makes commands run in a new pseudo-terminal
+	// of this size. Stdin is copied to the terminal and its output,
+	// containing both stdout and stderr, is written to Stdout.
+	Terminal *TerminalSize
+	// Sandbox, if not nil, makes commands run isolated from
+	// the system. It's supported only on Linux.
+	Sandbox *sandbox.Options
+	// Redactor, if not nil, masks secrets in the output
+	// written to Stdout and Stderr, and kept in the result.
+	Redactor *redact.Redactor
+	// InheritProcessGroup makes commands run in the process group
+	// of the current process instead of a new one. It's used when
+	// the current process leads a group which is signaled as a whole,
+	// like runme started in background.
+	InheritProcessGroup bool
 }
 
-var supportedExecutables = []string{
-	"bash",
-	"bat", // fallback to sh
-	"sh",
-	"sh-raw",
-	"shell",
-	"zsh",
-	"go",
+type TerminalSize struct {
+	Cols int
+	Rows int
 }
 
-func IsSupported(lang string) bool {
-	for _, item := range supportedExecutables {
-		if item == lang {
-			return true
+// command returns a command connected to the base's
+// directory, environment, and standard streams.
+func (b *Base) command(ctx context.Context, name string, args ...string) *command {
+	c := exec.Command(name, args...)
+	c.Dir = b.Dir
+	c.Env = b.Env
+	c.Stdin = b.Stdin
+	c.Stdout = b.Stdout
+	c.Stderr = b.Stderr
+
+	gracePeriod := b.GracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = DefaultGracePeriod
+	}
+
+	cmd := &command{
+		Cmd:         c,
+		ctx:         ctx,
+		gracePeriod: gracePeriod,
+		terminal:    b.Terminal,
+		// Commands run in a pseudo-terminal or a sandbox always lead a new session.
+		inheritGroup: b.InheritProcessGroup && b.Terminal == nil && b.Sandbox == nil,
+		stdoutTail:   newTailBuffer(b.TailSize),
+		stderrTail:   newTailBuffer(b.TailSize),
+	}
+
+	if b.Sandbox != nil {
+		opts := *b.Sandbox
+		opts.ReadOnly = append([]string(nil), opts.ReadOnly...)
+		opts.Writable = append([]string(nil), opts.Writable...)
+		cmd.sandbox = &opts
+	}
+
+	if cmd.stdoutTail != nil {
+		c.Stdout = teeWriter(b.Stdout, cmd.stdoutTail)
+		c.Stderr = teeWriter(b.Stderr, cmd.stderrTail)
+	}
+
+	if b.Redactor != nil {
+		c.Stdout = cmd.redactWriter(c.Stdout, b.Redactor)
+		c.Stderr = cmd.redactWriter(c.Stderr, b.Redactor)
+	}
+
+	return cmd
+}
+
+func teeWriter(w io.Writer, tail *tailBuffer) io.Writer {
+	if w == nil {
+		return tail
+	}
+	return io.MultiWriter(w, tail)
+}
+
+// command is run in its own process group. When ctx is done, the signal
+// from the context, SIGTERM by default, is sent to the whole group.
+// If the command does not exit within the grace period, the group is killed.
+// If inheritGroup is true, the command stays in the current process group
+// and only the command itself is signaled.
+type command struct {
+	*exec.Cmd
+	ctx          context.Context
+	gracePeriod  time.Duration
+	terminal     *TerminalSize
+	inheritGroup bool
+	stdoutTail   *tailBuffer
+	stderrTail   *tailBuffer
+	sandbox      *sandbox.Options
+	redactors    []*redact.Writer
+}
+
+func (c *command) redactWriter(w io.Writer, r *redact.Redactor) io.Writer {
+	if w == nil {
+		return nil
+	}
+	rw := redact.NewWriter(w, r)
+	c.redactors = append(c.redactors, rw)
+	return rw
+}
+
+// sandboxPaths makes paths, used by runme to pass files to the command,
+// visible in the sandbox. They are writable unless readOnly is true.
+func (c *command) sandboxPaths(readOnly bool, paths ...string) {
+	if c.sandbox == nil {
+		return
+	}
+	if readOnly {
+		c.sandbox.ReadOnly = append(c.sandbox.ReadOnly, paths...)
+	} else {
+		c.sandbox.Writable = append(c.sandbox.Writable, paths...)
+	}
+}
+
+func (c *command) Run() (*Result, error) {
+	var (
+		term          *terminal
+		stdin, stdout = c.Stdin, c.Stdout
+	)
+
+	if c.terminal != nil {
+		var err error
+		term, err = openTerminal(c.Cmd, *c.terminal)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = term.close() }()
+	} else if c.sandbox == nil && !c.inheritGroup {
+		// Sandboxed commands are made leaders of a new session by sandbox.Wrap.
+		restore := setProcessGroup(c.Cmd)
+		defer restore()
+	}
+
+	if c.sandbox != nil {
+		cleanup, err := sandbox.Wrap(c.Cmd, *c.sandbox)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+	}
+
+	start := time.Now()
+
+	if err := c.Start(); err != nil {
+		if c.sandbox != nil {
+			return nil, sandbox.StartError(err)
+		}
+		return nil, err
+	}
+
+	if term != nil {
+		term.start(stdin, stdout)
+	}
+
+	done := make(chan struct{})
+	terminated := make(chan struct{})
+
+	go func() {
+		defer close(terminated)
+
+		select {
+		case <-done:
+			return
+		case <-c.ctx.Done():
+		}
+
+		_ = c.signal(signalFromContext(c.ctx))
+
+		timer := time.NewTimer(c.gracePeriod)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+		case <-timer.C:
+			_ = c.kill()
+		}
+	}()
+
+	err := c.Wait()
+	close(done)
+	<-terminated
+
+	// The output must be fully copied before the result is created.
+	if term != nil {
+		if tErr := term.close(); err == nil {
+			err = tErr
+		}
+	}
+
+	for _, rw := range c.redactors {
+		if fErr := rw.Flush(); err == nil {
+			err = fErr
 		}
 	}
-	return false
+
+	result := newResult(start, c.ProcessState, c.stdoutTail, c.stderrTail)
+
+	if sig, ok := result.Signal.(syscall.Signal); ok {
+		return result, &SignalError{Signal: sig}
+	}
+
+	return result, err
+}
+
+// signal sends sig to the command's process group or,
+// if it inherited the current one, to the command only.
+func (c *command) signal(sig os.Signal) error {
+	if c.inheritGroup {
+		return c.Process.Signal(sig)
+	}
+	return signalProcessGroup(c.Process, sig)
+}
+
+func (c *command) kill() error {
+	if c.inheritGroup {
+		return c.Process.Kill()
+	}
+	return killProcessGroup(c.Process)
 }
 
 func IsShell(block *document.CodeBlock) bool {
@@ -1,19 +1,41 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/imports"
 )
 
+// goModulePath is the path of the temporary module
+// in which snippets are built.
+const goModulePath = "runme/snippet"
+
+// Go builds and runs Go code. Snippets without a package clause
+// are wrapped in the main function and missing imports are added.
+// The code is built in a temporary module which inherits requirements
+// of the module containing Dir, if any, and can import its packages.
+// Built binaries are cached.
 type Go struct {
 	*Base
 	Source string
+	// CacheDir is a directory where built binaries are kept.
+	// If empty, "runme/go" in the user's cache directory is used.
+	CacheDir string
 }
 
 var _ Executable = (*Go)(nil)
@@ -24,34 +46,324 @@ func (g *Go) DryRun(ctx context.Context, w io.Writer) {
 		_, _ = fmt.Fprintf(w, "failed to find %q executable: %s\n", "go", err)
 	}
 
-	_, _ = fmt.Fprintf(w, "// go run main.go in $TEMP\n\n")
-	_, _ = fmt.Fprintf(w, "%s\n", g.Source)
+	_, _ = fmt.Fprintf(w, "// go build main.go in a temporary module in $TEMP\n\n")
+	_, _ = fmt.Fprintf(w, "%s\n", goMainSource(g.Source))
 }
 
-func (g *Go) Run(ctx context.Context) error {
+func (g *Go) Run(ctx context.Context) (*Result, error) {
 	executable, err := exec.LookPath("go")
 	if err != nil {
-		return errors.Wrapf(err, "failed to find %q executable", "go")
+		return nil, errors.Wrapf(err, "failed to find %q executable", "go")
+	}
+
+	binary, cleanup, err := g.build(ctx, executable)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	cmd := g.Base.command(ctx, binary)
+	cmd.sandboxPaths(true, filepath.Dir(binary))
+	result, err := cmd.Run()
+	return result, errors.Wrap(err, "failed to run Go code")
+}
+
+// build returns the path to the binary built from the source and
+// a function removing it. Binaries are cached unless they import
+// packages of the project or of modules replaced with local
+// directories as these can change in the meantime.
+func (g *Go) build(ctx context.Context, executable string) (string, func(), error) {
+	noop := func() {}
+
+	goEnv, err := g.goCommand(ctx, executable, "", "env", "GOVERSION", "GOOS", "GOARCH").Output()
+	if err != nil {
+		return "", noop, errors.Wrap(err, "failed to get Go environment")
+	}
+	var goVersion string
+	if fields := strings.Fields(string(goEnv)); len(fields) > 0 {
+		goVersion = fields[0]
+	}
+
+	source := goMainSource(g.Source)
+
+	mod, err := newGoModule(g.Dir, goVersion)
+	if err != nil {
+		return "", noop, err
+	}
+
+	cacheDir := g.CacheDir
+	if cacheDir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", noop, errors.Wrap(err, "failed to find cache dir")
+		}
+		cacheDir = filepath.Join(userCacheDir, "runme", "go")
+	}
+
+	h := sha256.New()
+	for _, data := range [][]byte{goEnv, mod.modFile, mod.goSum, []byte(source)} {
+		_, _ = fmt.Fprintf(h, "%d\n", len(data))
+		_, _ = h.Write(data)
+	}
+
+	binary := filepath.Join(cacheDir, hex.EncodeToString(h.Sum(nil)))
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+
+	if _, err := os.Stat(binary); err == nil {
+		return binary, noop, nil
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return "", noop, errors.Wrap(err, "failed to create cache dir")
+	}
+
+	// Binaries are built in the cache dir and renamed
+	// so that concurrent runs never see a partial one.
+	tmpDir, err := os.MkdirTemp(cacheDir, "build-*")
+	if err != nil {
+		return "", noop, errors.Wrap(err, "failed to create a temp dir")
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+
+	output, source, err := g.buildIn(ctx, executable, tmpDir, mod, source, filepath.Base(binary))
+	if err != nil {
+		cleanup()
+		return "", noop, err
+	}
+
+	if mod.isImportedBy(source) {
+		return output, cleanup, nil
+	}
+
+	defer cleanup()
+
+	if err := os.Rename(output, binary); err != nil {
+		return "", noop, errors.Wrap(err, "failed to cache binary")
+	}
+
+	return binary, noop, nil
+}
+
+// buildIn writes the module to dir and builds it. It returns the path
+// to the binary with the given name and the source with fixed imports.
+func (g *Go) buildIn(ctx context.Context, executable, dir string, mod *goModule, source, name string) (string, string, error) {
+	files := map[string][]byte{"go.mod": mod.modFile, "go.sum": mod.goSum}
+	for filename, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, filename), data, 0o600); err != nil {
+			return "", "", errors.Wrapf(err, "failed to write %s", filename)
+		}
+	}
+
+	mainFile := filepath.Join(dir, "main.go")
+
+	// Syntax errors are left to be reported by the compiler.
+	if fixed, err := imports.Process(mainFile, []byte(source), nil); err == nil {
+		source = string(fixed)
+	}
+
+	if err := os.WriteFile(mainFile, []byte(source), 0o600); err != nil {
+		return "", "", errors.Wrap(err, "failed to write source to file")
+	}
+
+	output := filepath.Join(dir, name)
+
+	// -mod=mod adds requirements of imported packages
+	// which are not required by the project.
+	out, err := g.goCommand(ctx, executable, dir, "build", "-mod=mod", "-o", output, ".").CombinedOutput()
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to build Go code: %s", bytes.TrimSpace(out))
 	}
 
-	tmpDir, err := os.MkdirTemp("", "runme-*")
+	return output, source, nil
+}
+
+func (g *Go) goCommand(ctx context.Context, executable, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.Dir = dir
+	cmd.Env = g.Env
+	return cmd
+}
+
+// goMainSource returns source as a main package. Snippets without
+// a package clause are wrapped: their import declarations are kept
+// at the top and the following statements make up the main function.
+func goMainSource(source string) string {
+	fset := token.NewFileSet()
+
+	if _, err := parser.ParseFile(fset, "", source, parser.PackageClauseOnly); err == nil {
+		return source
+	}
+
+	const header = "package main\n\n"
+
+	// Snippets with only declarations, like func main, are not wrapped.
+	if _, err := parser.ParseFile(fset, "", header+source, 0); err == nil {
+		return header + source
+	}
+
+	f, err := parser.ParseFile(fset, "", header+source, parser.ImportsOnly)
 	if err != nil {
-		return errors.Wrapf(err, "failed to create a temp dir")
+		return header + source
 	}
-	defer os.RemoveAll(tmpDir)
 
-	mainFile := filepath.Join(tmpDir, "main.go")
+	end := 0
+	if len(f.Decls) > 0 {
+		end = fset.Position(f.Decls[len(f.Decls)-1].End()).Offset - len(header)
+	}
+
+	importDecls, body := strings.TrimSpace(source[:end]), strings.TrimSpace(source[end:])
+	if importDecls != "" {
+		importDecls += "\n\n"
+	}
 
-	err = os.WriteFile(mainFile, []byte(g.Source), 0o600)
+	return header + importDecls + "func main() {\n" + body + "\n}\n"
+}
+
+// goModule is the temporary module in which the source is built.
+type goModule struct {
+	modFile []byte
+	goSum   []byte
+	// localPaths are paths of modules replaced with local directories,
+	// including the inherited module, if any. Their contents can change
+	// without changing the module files.
+	localPaths []string
+}
+
+// newGoModule creates the temporary module. If dir is in a module, its
+// requirements and replacements are inherited and the module itself is
+// replaced with its directory so that its packages can be imported.
+// Otherwise, the go directive is set to the language version of goVersion.
+func newGoModule(dir, goVersion string) (*goModule, error) {
+	f := &modfile.File{}
+	if err := f.AddModuleStmt(goModulePath); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	projectFile, projectDir, err := findGoMod(dir)
 	if err != nil {
-		return errors.Wrapf(err, "failed to write source to file")
+		return nil, err
 	}
 
-	c := exec.CommandContext(ctx, executable, "run", mainFile)
-	c.Dir = g.Dir
-	c.Stderr = g.Stderr
-	c.Stdout = g.Stdout
-	c.Stdin = g.Stdin
+	if projectFile == nil {
+		// Like "go mod init", for example, go1.20.3 is 1.20.
+		version := strings.TrimPrefix(goVersion, "go")
+		if parts := strings.SplitN(version, ".", 3); len(parts) >= 2 {
+			version = parts[0] + "." + parts[1]
+		}
+		if modfile.GoVersionRE.MatchString(version) {
+			if err := f.AddGoStmt(version); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		return &goModule{modFile: modfile.Format(f.Syntax)}, nil
+	}
+
+	mod := &goModule{}
+
+	if projectFile.Go != nil {
+		if err := f.AddGoStmt(projectFile.Go.Version); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	for _, r := range projectFile.Require {
+		f.AddNewRequire(r.Mod.Path, r.Mod.Version, r.Indirect)
+	}
 
-	return errors.Wrapf(c.Run(), "failed to run command %q", "go run main.go")
+	for _, r := range projectFile.Replace {
+		newPath := r.New.Path
+		if modfile.IsDirectoryPath(newPath) && !filepath.IsAbs(newPath) {
+			newPath = filepath.Join(projectDir, newPath)
+		}
+		if err := f.AddReplace(r.Old.Path, r.Old.Version, newPath, r.New.Version); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if modfile.IsDirectoryPath(newPath) {
+			mod.localPaths = append(mod.localPaths, r.Old.Path)
+		}
+	}
+
+	if projectFile.Module != nil {
+		projectPath := projectFile.Module.Mod.Path
+		f.AddNewRequire(projectPath, "v0.0.0", false)
+		if err := f.AddReplace(projectPath, "", projectDir, ""); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		mod.localPaths = append(mod.localPaths, projectPath)
+	}
+
+	mod.modFile = modfile.Format(f.Syntax)
+
+	mod.goSum, err = os.ReadFile(filepath.Join(projectDir, "go.sum"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrap(err, "failed to read go.sum")
+	}
+
+	return mod, nil
+}
+
+// isImportedBy reports whether source imports packages of the project
+// or of modules replaced with local directories.
+func (m *goModule) isImportedBy(source string) bool {
+	if len(m.localPaths) == 0 {
+		return false
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", source, parser.ImportsOnly)
+	if err != nil {
+		return false
+	}
+
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		for _, modPath := range m.localPaths {
+			if path == modPath || strings.HasPrefix(path, modPath+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// findGoMod looks for go.mod in dir and its parents. It returns
+// the parsed file and its directory or nil if it's not found.
+func findGoMod(dir string) (*modfile.File, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+
+	for {
+		path := filepath.Join(dir, "go.mod")
+
+		data, err := os.ReadFile(path)
+		if err == nil {
+			f, err := modfile.Parse(path, data, nil)
+			if err != nil {
+				// Directives added in newer Go versions can't be parsed
+				// strictly. In lax mode, they are skipped together with
+				// replacements.
+				f, err = modfile.ParseLax(path, data, nil)
+			}
+			if err != nil {
+				return nil, "", errors.Wrap(err, "failed to parse go.mod")
+			}
+			return f, dir, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, "", errors.Wrap(err, "failed to read go.mod")
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, "", nil
+		}
+		dir = parent
+	}
 }
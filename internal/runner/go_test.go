@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoMainSource(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "Package",
+			source:   "package main\n\nfunc main() {}\n",
+			expected: "package main\n\nfunc main() {}\n",
+		},
+		{
+			name:     "Declarations",
+			source:   "import \"fmt\"\n\nfunc main() { fmt.Println(1) }\n",
+			expected: "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println(1) }\n",
+		},
+		{
+			name:     "Statements",
+			source:   "x := 1\nfmt.Println(x)\n",
+			expected: "package main\n\nfunc main() {\nx := 1\nfmt.Println(x)\n}\n",
+		},
+		{
+			name:     "StatementsWithImports",
+			source:   "import (\n\t\"fmt\"\n)\nimport \"os\"\n\nfmt.Println(os.Args)\n",
+			expected: "package main\n\nimport (\n\t\"fmt\"\n)\nimport \"os\"\n\nfunc main() {\nfmt.Println(os.Args)\n}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, goMainSource(tc.source))
+		})
+	}
+}
+
+func TestNewGoModule(t *testing.T) {
+	projectDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(`module example.com/project
+
+go 1.19
+
+require github.com/pkg/errors v0.9.1
+
+replace github.com/pkg/errors => ./third_party/errors
+`), 0o600)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(projectDir, "go.sum"), []byte("sum\n"), 0o600)
+	require.NoError(t, err)
+
+	subDir := filepath.Join(projectDir, "cmd")
+	require.NoError(t, os.Mkdir(subDir, 0o700))
+
+	mod, err := newGoModule(subDir, "go1.20.3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/pkg/errors", "example.com/project"}, mod.localPaths)
+	assert.Equal(t, "sum\n", string(mod.goSum))
+	assert.Contains(t, string(mod.modFile), "module runme/snippet\n")
+	assert.Contains(t, string(mod.modFile), "go 1.19\n")
+	assert.Contains(t, string(mod.modFile), "github.com/pkg/errors v0.9.1")
+	assert.Contains(t, string(mod.modFile), "github.com/pkg/errors => "+filepath.Join(projectDir, "third_party", "errors"))
+	assert.Contains(t, string(mod.modFile), "example.com/project => "+projectDir)
+
+	assert.True(t, mod.isImportedBy("package main\n\nimport \"example.com/project/pkg\"\n"))
+	assert.True(t, mod.isImportedBy("package main\n\nimport \"github.com/pkg/errors\"\n"))
+	assert.False(t, mod.isImportedBy("package main\n\nimport \"example.com/projector\"\n"))
+
+	mod, err = newGoModule(t.TempDir(), "go1.20.3")
+	require.NoError(t, err)
+	assert.Equal(t, "module runme/snippet\n\ngo 1.20\n", string(mod.modFile))
+	assert.False(t, mod.isImportedBy("package main\n\nimport \"example.com/project/pkg\"\n"))
+}
+
+func TestGo_Run(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+
+	projectDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(`module example.com/project
+
+go 1.19
+
+require example.com/lib v0.0.0
+
+replace example.com/lib => ./lib
+`), 0o600)
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(projectDir, "greet"), 0o700))
+	err = os.WriteFile(filepath.Join(projectDir, "greet", "greet.go"), []byte("package greet\n\nconst Hello = \"hello\"\n"), 0o600)
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(projectDir, "lib"), 0o700))
+	err = os.WriteFile(filepath.Join(projectDir, "lib", "go.mod"), []byte("module example.com/lib\n\ngo 1.19\n"), 0o600)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(projectDir, "lib", "lib.go"), []byte("package lib\n\nconst Name = \"lib\"\n"), 0o600)
+	require.NoError(t, err)
+
+	cacheDir := t.TempDir()
+
+	run := func(source string) string {
+		var stdout bytes.Buffer
+		g := &Go{Base: &Base{Dir: projectDir, Stdout: &stdout}, Source: source, CacheDir: cacheDir}
+		_, err := g.Run(context.Background())
+		require.NoError(t, err)
+		return stdout.String()
+	}
+
+	// Missing imports are added to snippets.
+	assert.Equal(t, "hello world\n", run(`fmt.Println(strings.Join([]string{"hello", "world"}, " "))`))
+	assert.Equal(t, "hello world\n", run(`fmt.Println(strings.Join([]string{"hello", "world"}, " "))`))
+
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// Binaries importing packages of the project are not cached.
+	assert.Equal(t, "hello\n", run("import \"example.com/project/greet\"\n\nfmt.Println(greet.Hello)"))
+
+	// Neither are binaries importing modules replaced with local directories.
+	assert.Equal(t, "lib\n", run("import \"example.com/lib\"\n\nfmt.Println(lib.Name)"))
+
+	entries, err = os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	g := &Go{Base: &Base{Dir: projectDir}, Source: "fmt.Println(", CacheDir: cacheDir}
+	_, err = g.Run(context.Background())
+	assert.ErrorContains(t, err, "failed to build Go code")
+}
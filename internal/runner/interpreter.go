@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// InputMode defines how a code block's source is passed to an interpreter.
+type InputMode string
+
+const (
+	// InputFile writes the source to a temporary file and passes
+	// its path as the last argument. It's the default mode.
+	InputFile InputMode = "file"
+	// InputStdin writes the source to the interpreter's stdin.
+	InputStdin InputMode = "stdin"
+	// InputArg passes the source as the last argument,
+	// for example, after "-c" or "-e".
+	InputArg InputMode = "arg"
+)
+
+// Interpreter is a command template describing
+// how to run code blocks of a given language.
+type Interpreter struct {
+	Command   string    `yaml:"command"`
+	Args      []string  `yaml:"args,omitempty"`
+	Input     InputMode `yaml:"input,omitempty"`
+	Extension string    `yaml:"extension,omitempty"`
+}
+
+func (i Interpreter) Validate() error {
+	if i.Command == "" {
+		return errors.New("command is required")
+	}
+
+	switch i.Input {
+	case "", InputFile, InputStdin, InputArg:
+		return nil
+	default:
+		return errors.Errorf("unknown input %q; expected one of: %s, %s, %s", i.Input, InputFile, InputStdin, InputArg)
+	}
+}
+
+func (i Interpreter) filename() string {
+	if i.Extension == "" {
+		return "main"
+	}
+	return "main." + strings.TrimPrefix(i.Extension, ".")
+}
+
+// Script runs a code block using an Interpreter.
+type Script struct {
+	*Base
+	Interpreter Interpreter
+	Source      string
+}
+
+var _ Executable = (*Script)(nil)
+
+func (s *Script) DryRun(ctx context.Context, w io.Writer) {
+	_, err := exec.LookPath(s.Interpreter.Command)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "failed to find %q executable: %s\n", s.Interpreter.Command, err)
+	}
+
+	command := strings.Join(append([]string{s.Interpreter.Command}, s.Interpreter.Args...), " ")
+
+	switch s.Interpreter.Input {
+	case InputStdin:
+		_, _ = fmt.Fprintf(w, "# %s < source\n\n", command)
+	case InputArg:
+		_, _ = fmt.Fprintf(w, "# %s source\n\n", command)
+	default:
+		_, _ = fmt.Fprintf(w, "# %s %s in $TEMP\n\n", command, s.Interpreter.filename())
+	}
+
+	_, _ = fmt.Fprintf(w, "%s\n", s.Source)
+}
+
+func (s *Script) Run(ctx context.Context) (*Result, error) {
+	executable, err := exec.LookPath(s.Interpreter.Command)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find %q executable", s.Interpreter.Command)
+	}
+
+	// Copy args so that appending to them
+	// does not modify the interpreter.
+	args := append([]string(nil), s.Interpreter.Args...)
+
+	var result *Result
+
+	switch s.Interpreter.Input {
+	case InputStdin, InputArg:
+		if s.Interpreter.Input == InputArg {
+			args = append(args, s.Source)
+		}
+
+		c := s.command(ctx, executable, args...)
+		if s.Interpreter.Input == InputStdin {
+			c.Stdin = strings.NewReader(s.Source)
+		}
+
+		result, err = c.Run()
+	default:
+		result, err = runSourceFile(ctx, s.Base, executable, args, s.Interpreter.filename(), s.Source)
+	}
+
+	return result, errors.Wrapf(err, "failed to run command %q", s.Interpreter.Command)
+}
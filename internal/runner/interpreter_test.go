@@ -0,0 +1,45 @@
+//go:build !windows
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScript_Run(t *testing.T) {
+	testCases := []struct {
+		name        string
+		interpreter Interpreter
+	}{
+		{name: "File", interpreter: Interpreter{Command: "sh", Extension: ".sh"}},
+		{name: "Stdin", interpreter: Interpreter{Command: "sh", Input: InputStdin}},
+		{name: "Arg", interpreter: Interpreter{Command: "sh", Args: []string{"-c"}, Input: InputArg}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			s := &Script{
+				Base:        &Base{Dir: t.TempDir(), Stdout: stdout, Stderr: stdout},
+				Interpreter: tc.interpreter,
+				Source:      "echo hello\necho world",
+			}
+			result, err := s.Run(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 0, result.ExitCode)
+			assert.Equal(t, "hello\nworld\n", stdout.String())
+			assert.Equal(t, tc.interpreter.Args, s.Interpreter.Args)
+		})
+	}
+}
+
+func TestInterpreter_Validate(t *testing.T) {
+	assert.NoError(t, Interpreter{Command: "ruby"}.Validate())
+	assert.Error(t, Interpreter{}.Validate())
+	assert.Error(t, Interpreter{Command: "ruby", Input: "pipe"}.Validate())
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+type Node struct {
+	*Base
+	Source string
+}
+
+var _ Executable = (*Node)(nil)
+
+func (n *Node) DryRun(ctx context.Context, w io.Writer) {
+	_, err := exec.LookPath("node")
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "failed to find %q executable: %s\n", "node", err)
+	}
+
+	_, _ = fmt.Fprintf(w, "// node %s in $TEMP\n\n", n.filename())
+	_, _ = fmt.Fprintf(w, "%s\n", n.Source)
+}
+
+func (n *Node) Run(ctx context.Context) (*Result, error) {
+	executable, err := exec.LookPath("node")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find %q executable", "node")
+	}
+
+	filename := n.filename()
+	result, err := runSourceFile(ctx, n.Base, executable, nil, filename, n.Source)
+	return result, errors.Wrapf(err, "failed to run command %q", "node "+filename)
+}
+
+var esmStatementRe = regexp.MustCompile(`(?m)^\s*(import|export)\s`)
+
+// filename returns a name of the file the source is written to.
+// Snippets using import or export statements are ES modules
+// and require the .mjs extension; others are run as CommonJS
+// so that require() keeps working.
+func (n *Node) filename() string {
+	if esmStatementRe.MatchString(n.Source) {
+		return "main.mjs"
+	}
+	return "main.js"
+}
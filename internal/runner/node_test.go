@@ -0,0 +1,18 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNode_filename(t *testing.T) {
+	n := &Node{Source: "const fs = require('fs')\nconsole.log(fs.existsSync('.'))"}
+	assert.Equal(t, "main.js", n.filename())
+
+	n.Source = "import fs from 'fs'\nconsole.log(fs.existsSync('.'))"
+	assert.Equal(t, "main.mjs", n.filename())
+
+	n.Source = "console.log('import x')"
+	assert.Equal(t, "main.js", n.filename())
+}
@@ -0,0 +1,63 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/mattn/go-isatty"
+	"golang.org/x/sys/unix"
+)
+
+// setProcessGroup makes the command a leader of a new process group
+// so that processes it starts can be signaled together with it.
+// If stdin is a terminal, the group is moved to the foreground to allow
+// the command to read from it. The returned function moves the current
+// process group back to the foreground and must be called after
+// the command exits.
+func setProcessGroup(c *exec.Cmd) func() {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	f, ok := c.Stdin.(*os.File)
+	if !ok || !isatty.IsTerminal(f.Fd()) {
+		return func() {}
+	}
+
+	c.SysProcAttr.Foreground = true
+	c.SysProcAttr.Ctty = int(f.Fd())
+
+	return func() {
+		// A background process group changing the foreground
+		// process group receives SIGTTOU which stops it by default.
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+		_ = unix.IoctlSetPointerInt(int(f.Fd()), unix.TIOCSPGRP, syscall.Getpgrp())
+	}
+}
+
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		s = syscall.SIGTERM
+	}
+	err := syscall.Kill(-p.Pid, s)
+	if err == syscall.ESRCH {
+		return nil
+	}
+	return err
+}
+
+func killProcessGroup(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGKILL)
+}
+
+func signalName(sig syscall.Signal) string {
+	if name := unix.SignalName(sig); name != "" {
+		return name
+	}
+	return sig.String()
+}
@@ -0,0 +1,97 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellRaw_CancelSignal(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+
+	ctx, cancel := WithCancelSignal(context.Background())
+
+	// Non-interactive shells ignore SIGINT in background processes
+	// so the grace period is used to kill it.
+	shell := &ShellRaw{
+		Base: &Base{Stdout: io.Discard, Stderr: io.Discard, GracePeriod: 200 * time.Millisecond},
+		Cmds: []string{"sleep 30 &", "echo $! > " + pidFile, "wait"},
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := shell.Run(ctx)
+		errc <- err
+	}()
+
+	pid := waitForPidFile(t, pidFile)
+
+	cancel(syscall.SIGINT)
+
+	err := <-errc
+	var signalErr *SignalError
+	require.True(t, errors.As(err, &signalErr), "unexpected error: %v", err)
+	assert.Equal(t, syscall.SIGINT, signalErr.Signal)
+	assert.Equal(t, 130, signalErr.ExitCode())
+	assert.Contains(t, err.Error(), "terminated by SIGINT")
+
+	// The background process started by the shell is stopped as well.
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(pid, 0) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestShellRaw_GracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	shell := &ShellRaw{
+		Base: &Base{Stdout: io.Discard, Stderr: io.Discard, GracePeriod: 100 * time.Millisecond},
+		Cmds: []string{"trap '' TERM", "sleep 30"},
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := shell.Run(ctx)
+		errc <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errc:
+		var signalErr *SignalError
+		require.True(t, errors.As(err, &signalErr), "unexpected error: %v", err)
+		assert.Equal(t, syscall.SIGKILL, signalErr.Signal)
+	case <-time.After(5 * time.Second):
+		t.Fatal("command was not killed after the grace period")
+	}
+}
+
+func waitForPidFile(t *testing.T, path string) int {
+	t.Helper()
+
+	var pid int
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return pid
+}
@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup is a no-op as Windows does not
+// support signaling process groups.
+func setProcessGroup(c *exec.Cmd) func() {
+	return func() {}
+}
+
+// signalProcessGroup kills the process as Windows
+// does not support sending signals.
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	return p.Kill()
+}
+
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+func signalName(sig syscall.Signal) string {
+	return sig.String()
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Python struct {
+	*Base
+	Source string
+	// Interpreter is a name or a path of the Python interpreter,
+	// or a path to a virtualenv. Relative paths are resolved
+	// against Dir. If empty, an active virtualenv ($VIRTUAL_ENV),
+	// python3 and python are tried in this order.
+	Interpreter string
+}
+
+var _ Executable = (*Python)(nil)
+
+func (p *Python) DryRun(ctx context.Context, w io.Writer) {
+	executable, err := p.lookPath()
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "failed to find Python interpreter: %s\n", err)
+		executable = "python3"
+	}
+
+	_, _ = fmt.Fprintf(w, "# %s main.py in $TEMP\n\n", executable)
+	_, _ = fmt.Fprintf(w, "%s\n", p.Source)
+}
+
+func (p *Python) Run(ctx context.Context) (*Result, error) {
+	executable, err := p.lookPath()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find Python interpreter")
+	}
+
+	result, err := runSourceFile(ctx, p.Base, executable, nil, "main.py", p.Source)
+	return result, errors.Wrapf(err, "failed to run command %q", filepath.Base(executable)+" main.py")
+}
+
+func (p *Python) lookPath() (path string, err error) {
+	candidates := []string{"python3", "python"}
+
+	if p.Interpreter != "" {
+		candidates = []string{p.Interpreter}
+	} else if venv := os.Getenv("VIRTUAL_ENV"); venv != "" {
+		candidates = append([]string{venv}, candidates...)
+	}
+
+	for _, name := range candidates {
+		path, err = lookPythonPath(p.Dir, name)
+		if err == nil {
+			return path, nil
+		}
+	}
+
+	return "", err
+}
+
+func lookPythonPath(dir, name string) (string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	// A virtualenv directory keeps its interpreter in bin/
+	// or Scripts/ on Windows.
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		if runtime.GOOS == "windows" {
+			return exec.LookPath(filepath.Join(path, "Scripts", "python.exe"))
+		}
+		return exec.LookPath(filepath.Join(path, "bin", "python"))
+	}
+
+	if strings.ContainsAny(name, `/\`) {
+		name = path
+	}
+
+	return exec.LookPath(name)
+}
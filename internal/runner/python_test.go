@@ -0,0 +1,42 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPython_lookPath(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".venv", "bin"), 0o700))
+	interpreter := filepath.Join(dir, ".venv", "bin", "python")
+	require.NoError(t, os.WriteFile(interpreter, []byte("#!/bin/sh\n"), 0o700))
+
+	p := &Python{Base: &Base{Dir: dir}}
+
+	p.Interpreter = ".venv"
+	path, err := p.lookPath()
+	require.NoError(t, err)
+	assert.Equal(t, interpreter, path)
+
+	p.Interpreter = ".venv/bin/python"
+	path, err = p.lookPath()
+	require.NoError(t, err)
+	assert.Equal(t, interpreter, path)
+
+	p.Interpreter = "missing/python"
+	_, err = p.lookPath()
+	require.Error(t, err)
+
+	p.Interpreter = ""
+	t.Setenv("VIRTUAL_ENV", filepath.Join(dir, ".venv"))
+	path, err = p.lookPath()
+	require.NoError(t, err)
+	assert.Equal(t, interpreter, path)
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stateful/runme/internal/document"
+)
+
+// Factory creates an Executable for a code block. The interpreter,
+// if not empty, overrides the default one and is honored
+// by executables which support it.
+type Factory func(base *Base, block *document.CodeBlock, interpreter string) Executable
+
+// Registry maps languages to factories of executables.
+type Registry struct {
+	factories map[string]Factory
+}
+
+// NewRegistry returns a Registry with the built-in executables registered.
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]Factory)}
+
+	shell := func(base *Base, block *document.CodeBlock, interpreter string) Executable {
+		// The shell attribute takes precedence over other interpreters.
+		if name := block.Attributes()["shell"]; name != "" {
+			interpreter = name
+		}
+		return &Shell{Base: base, Cmds: block.Lines(), Language: block.Language(), Interpreter: interpreter}
+	}
+	for _, lang := range []string{"bash", "bat", "fish", "sh", "shell", "zsh"} {
+		r.Register(lang, shell)
+	}
+
+	r.Register("sh-raw", func(base *Base, block *document.CodeBlock, _ string) Executable {
+		return &ShellRaw{Base: base, Cmds: block.Lines()}
+	})
+
+	r.Register("go", func(base *Base, block *document.CodeBlock, _ string) Executable {
+		return &Go{Base: base, Source: string(block.Content())}
+	})
+
+	node := func(base *Base, block *document.CodeBlock, _ string) Executable {
+		return &Node{Base: base, Source: string(block.Content())}
+	}
+	r.Register("javascript", node)
+	r.Register("js", node)
+
+	deno := func(base *Base, block *document.CodeBlock, _ string) Executable {
+		return &Deno{Base: base, Source: string(block.Content())}
+	}
+	r.Register("ts", deno)
+	r.Register("typescript", deno)
+
+	python := func(base *Base, block *document.CodeBlock, interpreter string) Executable {
+		return &Python{Base: base, Source: string(block.Content()), Interpreter: interpreter}
+	}
+	r.Register("py", python)
+	r.Register("python", python)
+
+	return r
+}
+
+// Register registers a factory for the language
+// replacing the existing one, if any.
+func (r *Registry) Register(lang string, f Factory) {
+	r.factories[lang] = f
+}
+
+// RegisterInterpreter registers a command template for the language.
+// The interpreter passed to the factory replaces the command.
+func (r *Registry) RegisterInterpreter(lang string, i Interpreter) {
+	r.Register(lang, func(base *Base, block *document.CodeBlock, interpreter string) Executable {
+		if interpreter != "" {
+			i.Command = interpreter
+		}
+		return &Script{Base: base, Interpreter: i, Source: string(block.Content())}
+	})
+}
+
+func (r *Registry) IsSupported(lang string) bool {
+	_, ok := r.factories[lang]
+	return ok
+}
+
+func (r *Registry) New(base *Base, block *document.CodeBlock, interpreter string) (Executable, error) {
+	f, ok := r.factories[block.Language()]
+	if !ok {
+		return nil, errors.Errorf("unknown executable: %q", block.Language())
+	}
+	return f(base, block, interpreter), nil
+}
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/renderer/cmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	data := []byte("```sh\necho 1\n```\n\n```ruby\nputs 1\n```\n\n```python\nprint(1)\n```\n\n```bash { shell=zsh }\necho 1\n```\n")
+	node, _, err := document.New(data, cmark.Render).Parse()
+	require.NoError(t, err)
+	blocks := document.CollectCodeBlocks(node)
+	require.Len(t, blocks, 4)
+
+	registry := NewRegistry()
+	assert.True(t, registry.IsSupported("sh"))
+	assert.False(t, registry.IsSupported("ruby"))
+
+	executable, err := registry.New(&Base{}, blocks[0], "")
+	require.NoError(t, err)
+	assert.IsType(t, &Shell{}, executable)
+	assert.Equal(t, "sh", executable.(*Shell).Language)
+
+	executable, err = registry.New(&Base{}, blocks[3], "bash")
+	require.NoError(t, err)
+	assert.Equal(t, "zsh", executable.(*Shell).Interpreter)
+
+	_, err = registry.New(&Base{}, blocks[1], "")
+	require.EqualError(t, err, `unknown executable: "ruby"`)
+
+	registry.RegisterInterpreter("ruby", Interpreter{Command: "ruby", Extension: "rb"})
+	executable, err = registry.New(&Base{}, blocks[1], "ruby3.2")
+	require.NoError(t, err)
+	assert.Equal(t, Interpreter{Command: "ruby3.2", Extension: "rb"}, executable.(*Script).Interpreter)
+
+	executable, err = registry.New(&Base{}, blocks[2], ".venv")
+	require.NoError(t, err)
+	assert.Equal(t, ".venv", executable.(*Python).Interpreter)
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Result describes a finished execution of a command.
+type Result struct {
+	// ExitCode is the exit code of the command. If it was terminated
+	// by a signal, it's 128 plus the signal number like in shells.
+	ExitCode int
+	// Signal is the signal which terminated the command, if any.
+	Signal    os.Signal
+	StartTime time.Time
+	EndTime   time.Time
+	// Stdout and Stderr contain trailing bytes of the output
+	// if Base.TailSize is positive.
+	Stdout []byte
+	Stderr []byte
+}
+
+func (r *Result) Duration() time.Duration {
+	return r.EndTime.Sub(r.StartTime)
+}
+
+func newResult(start time.Time, state *os.ProcessState, stdout, stderr *tailBuffer) *Result {
+	result := &Result{
+		ExitCode:  state.ExitCode(),
+		StartTime: start,
+		EndTime:   time.Now(),
+		Stdout:    stdout.Bytes(),
+		Stderr:    stderr.Bytes(),
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		result.Signal = status.Signal()
+		result.ExitCode = (&SignalError{Signal: status.Signal()}).ExitCode()
+	}
+	return result
+}
+
+// tailBuffer keeps the last size bytes written to it.
+// A nil tailBuffer discards all writes.
+type tailBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+func newTailBuffer(size int) *tailBuffer {
+	if size <= 0 {
+		return nil
+	}
+	return &tailBuffer{size: size}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	if b == nil {
+		return len(p), nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(p)
+	if len(p) >= b.size {
+		b.buf = append(b.buf[:0], p[len(p)-b.size:]...)
+		return n, nil
+	}
+
+	if overflow := len(b.buf) + len(p) - b.size; overflow > 0 {
+		b.buf = append(b.buf[:0], b.buf[overflow:]...)
+	}
+	b.buf = append(b.buf, p...)
+
+	return n, nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf...)
+}
@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+// Session holds the working directory and changes to the environment
+// made by shell commands so that they can be restored before executing
+// subsequent commands.
+type Session struct {
+	Dir string `json:"dir"`
+	// Env contains variables set or changed by commands as KEY=VALUE.
+	Env []string `json:"env"`
+	// Unset contains names of variables removed by commands.
+	Unset []string `json:"unset,omitempty"`
+}
+
+// ApplyEnv returns env with the session's changes applied.
+func (s *Session) ApplyEnv(env []string) []string {
+	env = MergeEnv(env, s.Env...)
+	return removeEnv(env, s.Unset...)
+}
+
+// removeEnv returns env without the named variables.
+func removeEnv(env []string, names ...string) []string {
+	if len(names) == 0 {
+		return env
+	}
+
+	removed := make(map[string]bool, len(names))
+	for _, name := range names {
+		removed[name] = true
+	}
+
+	result := make([]string, 0, len(env))
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		if !removed[key] {
+			result = append(result, kv)
+		}
+	}
+	return result
+}
+
+// sessionIgnoredEnv are variables managed by shells
+// which must not be carried over to next commands.
+var sessionIgnoredEnv = map[string]bool{
+	"_":      true,
+	"OLDPWD": true,
+	"PWD":    true,
+	"SHLVL":  true,
+}
+
+// sessionCapture extends a shell script to dump its working
+// directory and environment on exit into temporary files.
+type sessionCapture struct {
+	tmpDir string
+}
+
+func newSessionCapture() (*sessionCapture, error) {
+	tmpDir, err := os.MkdirTemp("", "runme-session-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a temp dir")
+	}
+	return &sessionCapture{tmpDir: tmpDir}, nil
+}
+
+func (c *sessionCapture) dirFile() string { return filepath.Join(c.tmpDir, "dir") }
+
+func (c *sessionCapture) envFile() string { return filepath.Join(c.tmpDir, "env") }
+
+// Script returns the script prepended by a trap which is run
+// on exit regardless of the script's exit status.
+func (c *sessionCapture) Script(script string) string {
+	return fmt.Sprintf("trap 'pwd > %q; env -0 > %q' EXIT\n%s", c.dirFile(), c.envFile(), script)
+}
+
+// Update stores the captured working directory and changes made
+// to startEnv, the environment the script was started with, in s.
+// If the script did not exit properly, s is left unchanged.
+func (c *sessionCapture) Update(s *Session, startEnv []string) error {
+	dir, err := os.ReadFile(c.dirFile())
+	if err != nil {
+		return errors.Wrap(err, "failed to read session working directory")
+	}
+
+	rawEnv, err := os.ReadFile(c.envFile())
+	if err != nil {
+		return errors.Wrap(err, "failed to read session environment")
+	}
+
+	start := make(map[string]string, len(startEnv))
+	for _, kv := range startEnv {
+		key, value, _ := strings.Cut(kv, "=")
+		start[key] = value
+	}
+
+	var (
+		changed []string
+		final   = make(map[string]bool)
+	)
+
+	for _, kv := range bytes.Split(rawEnv, []byte{0}) {
+		key, value, ok := strings.Cut(string(kv), "=")
+		if !ok || sessionIgnoredEnv[key] {
+			continue
+		}
+		final[key] = true
+		if prev, ok := start[key]; !ok || prev != value {
+			changed = append(changed, string(kv))
+		}
+	}
+
+	var unset []string
+	for _, kv := range startEnv {
+		key, _, _ := strings.Cut(kv, "=")
+		if !final[key] && !sessionIgnoredEnv[key] {
+			unset = append(unset, key)
+		}
+	}
+
+	s.Dir = strings.TrimSpace(string(dir))
+	s.Env = MergeEnv(removeEnv(s.Env, unset...), changed...)
+
+	// Variables set again are no longer unset.
+	var stillUnset []string
+	for _, name := range append(s.Unset, unset...) {
+		if !final[name] && !slices.Contains(stillUnset, name) {
+			stillUnset = append(stillUnset, name)
+		}
+	}
+	s.Unset = stillUnset
+
+	return nil
+}
+
+func (c *sessionCapture) Close() error {
+	return os.RemoveAll(c.tmpDir)
+}
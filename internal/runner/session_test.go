@@ -0,0 +1,55 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell_Session(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "web"), 0o700))
+
+	session := &Session{}
+	base := &Base{
+		Dir:     dir,
+		Env:     []string{"PATH=" + os.Getenv("PATH"), "STAGE=dev", "DEBUG=1"},
+		Stdout:  io.Discard,
+		Stderr:  io.Discard,
+		Session: session,
+	}
+
+	shell := &Shell{Base: base, Cmds: []string{"export API_URL=http://localhost STAGE=prod", "unset DEBUG", "cd web"}}
+	_, err := shell.Run(context.Background())
+	require.NoError(t, err)
+
+	resolvedDir, err := filepath.EvalSymlinks(filepath.Join(dir, "web"))
+	require.NoError(t, err)
+	assert.Equal(t, resolvedDir, session.Dir)
+
+	// Only changes to the environment are stored.
+	assert.ElementsMatch(t, []string{"API_URL=http://localhost", "STAGE=prod"}, session.Env)
+	assert.Equal(t, []string{"DEBUG"}, session.Unset)
+	assert.Equal(t, []string{"PATH=" + os.Getenv("PATH"), "STAGE=prod", "API_URL=http://localhost"}, session.ApplyEnv(base.Env))
+
+	// Variables unset before can be set again.
+	base.Env = session.ApplyEnv(base.Env)
+	shell = &Shell{Base: base, Cmds: []string{"export DEBUG=2"}}
+	_, err = shell.Run(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, session.Env, "DEBUG=2")
+	assert.Empty(t, session.Unset)
+
+	// The session is updated also when a command fails.
+	shell = &Shell{Base: base, Cmds: []string{"export STAGE=test", "false"}}
+	_, err = shell.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, session.Env, "STAGE=test")
+}
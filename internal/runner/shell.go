@@ -7,106 +7,224 @@ import (
 	"log"
 	"os"
 	"os/exec"
-	"strconv"
+	"path/filepath"
 	"strings"
 
-	"github.com/google/shlex"
 	"github.com/pkg/errors"
+	"mvdan.cc/sh/v3/syntax"
 )
 
 type Shell struct {
 	*Base
 	Cmds []string
+	// Language is the language of the block, like bash, zsh, or sh,
+	// which determines the shell. Blocks in shell, or without a known
+	// language, are run by $SHELL or /bin/sh.
+	Language string
+	// Interpreter, if not empty, is a name or a path
+	// of the shell overriding the one for Language.
+	Interpreter string
 }
 
 var _ Executable = (*Shell)(nil)
 
+// languageShells lists shells tried in order for block languages.
+var languageShells = map[string][]string{
+	"bash": {"bash"},
+	"zsh":  {"zsh", "bash"},
+	"sh":   {"sh"},
+	"fish": {"fish"},
+}
+
 func (s *Shell) DryRun(ctx context.Context, w io.Writer) {
-	sh, ok := os.LookupEnv("SHELL")
-	if !ok {
-		sh = "/bin/sh"
+	sh, err := s.lookPath()
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "failed to find shell: %s\n", err)
+		sh = defaultShell()
+	}
+
+	if err := validateScript(s.Cmds, sh); err != nil {
+		_, _ = fmt.Fprintf(w, "failed to parse script: %s\n", err)
 	}
 
 	var b strings.Builder
 
 	_, _ = b.WriteString(fmt.Sprintf("#!%s\n\n", sh))
 	_, _ = b.WriteString(fmt.Sprintf("// run in %q\n\n", s.Dir))
-	_, _ = b.WriteString(prepareScript(s.Cmds))
+	_, _ = b.WriteString(prepareScript(s.Cmds, sh))
 
-	_, err := w.Write([]byte(b.String()))
+	_, err = w.Write([]byte(b.String()))
 	if err != nil {
 		log.Fatalf("failed to write: %s", err)
 	}
 }
 
-func (s *Shell) Run(ctx context.Context) error {
-	sh, ok := os.LookupEnv("SHELL")
+func (s *Shell) Run(ctx context.Context) (*Result, error) {
+	sh, err := s.lookPath()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find shell")
+	}
+
+	if err := validateScript(s.Cmds, sh); err != nil {
+		return nil, errors.Wrap(err, "failed to parse script")
+	}
+
+	return execSingle(ctx, s.Base, sh, prepareScript(s.Cmds, sh))
+}
+
+// lookPath returns the shell which runs the script.
+func (s *Shell) lookPath() (string, error) {
+	if s.Interpreter != "" {
+		return exec.LookPath(s.Interpreter)
+	}
+
+	candidates, ok := languageShells[s.Language]
 	if !ok {
-		sh = "/bin/sh"
+		return defaultShell(), nil
+	}
+
+	for _, name := range candidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
 	}
 
-	return execSingle(ctx, sh, s.Dir, prepareScript(s.Cmds), s.Name, s.Stdin, s.Stdout, s.Stderr)
+	return "", errors.Errorf("no shell found for %s blocks, tried: %s", s.Language, strings.Join(candidates, ", "))
+}
+
+// defaultShell returns the user's shell or /bin/sh.
+func defaultShell() string {
+	if sh, ok := os.LookupEnv("SHELL"); ok && sh != "" {
+		return sh
+	}
+	return "/bin/sh"
+}
+
+// PrepareScript returns a script executing cmds in the shell sh
+// which exits on the first failed command if the shell supports it.
+func PrepareScript(cmds []string, sh string) string {
+	return prepareScript(cmds, sh)
 }
 
-func PrepareScript(cmds []string) string {
-	return prepareScript(cmds)
+// shellDialect describes the syntax and options supported by a shell.
+type shellDialect struct {
+	lang syntax.LangVariant
+	// parse is false if scripts can't be validated with any variant.
+	parse    bool
+	errexit  bool
+	pipefail bool
 }
 
-func prepareScript(cmds []string) string {
+// dialectOf returns a dialect of the shell based on its executable name.
+// Unknown shells are treated as POSIX shells.
+func dialectOf(sh string) shellDialect {
+	name := strings.TrimSuffix(filepath.Base(sh), ".exe")
+
+	switch name {
+	case "bash":
+		return shellDialect{lang: syntax.LangBash, parse: true, errexit: true, pipefail: true}
+	case "zsh":
+		// There is no zsh parser and bash can't parse all of zsh syntax.
+		return shellDialect{errexit: true, pipefail: true}
+	case "ksh", "mksh", "ksh93":
+		return shellDialect{lang: syntax.LangMirBSDKorn, parse: true, errexit: true, pipefail: true}
+	case "fish":
+		// fish has neither POSIX syntax nor an option
+		// to exit on the first failed command.
+		return shellDialect{}
+	default:
+		return shellDialect{lang: syntax.LangPOSIX, parse: true, errexit: true}
+	}
+}
+
+// options returns a line setting options making the script exit
+// on the first failed command, also within pipelines, as far as
+// the shell supports it.
+func (d shellDialect) options() string {
+	switch {
+	case d.pipefail:
+		return "set -e -o pipefail\n"
+	case d.errexit:
+		return "set -e\n"
+	default:
+		return ""
+	}
+}
+
+// prepareScript joins cmds, which are lines of a code block, into a script
+// for the shell sh. The lines are used as they are so that heredocs,
+// compound commands, continued lines, and quoting are kept intact.
+// They are preceded by options making the script exit on the first
+// failed command if the shell supports it.
+func prepareScript(cmds []string, sh string) string {
+	source := strings.Join(cmds, "\n")
+
 	var b strings.Builder
 
-	_, _ = b.WriteString("set -e -o pipefail;")
-
-	for _, cmd := range cmds {
-		detectedWord := false
-		lex := shlex.NewLexer(strings.NewReader(cmd))
-
-		for {
-			word, err := lex.Next()
-			if err != nil {
-				if err.Error() == "EOF found after escape character" {
-					// Handle the case when a line ends with "\"
-					// which should continue a single command.
-					_, _ = b.WriteString(" ")
-				} else if detectedWord {
-					_, _ = b.WriteString(";")
-				}
-				break
-			}
-
-			if detectedWord {
-				// Separate words with a space. It's done in this way
-				// to avoid trailing spaces.
-				_, _ = b.WriteString(" ")
-			} else {
-				detectedWord = true
-			}
-
-			if strings.Contains(word, " ") {
-				_, _ = b.WriteString(strconv.Quote(word))
-			} else {
-				_, _ = b.WriteString(word)
-			}
-		}
+	_, _ = b.WriteString(dialectOf(sh).options())
+
+	if strings.TrimSpace(source) == "" {
+		return b.String()
 	}
 
-	_, _ = b.WriteRune('\n')
+	_, _ = b.WriteString(source)
+	if !strings.HasSuffix(source, "\n") {
+		_, _ = b.WriteString("\n")
+	}
 
 	return b.String()
 }
 
-func execSingle(ctx context.Context, sh, dir, cmd string, name string, stdin io.Reader, stdout, stderr io.Writer) error {
-	c := exec.CommandContext(ctx, sh, []string{"-c", cmd}...)
-	c.Dir = dir
-	c.Stderr = stderr
-	c.Stdout = stdout
-	c.Stdin = stdin
+// validateScript parses cmds with the syntax of the shell sh and returns
+// the syntax error, if any. Scripts of shells which can't be parsed,
+// like zsh or fish, are not validated.
+func validateScript(cmds []string, sh string) error {
+	dialect := dialectOf(sh)
+	if !dialect.parse {
+		return nil
+	}
 
-	err := c.Run()
+	parser := syntax.NewParser(syntax.Variant(dialect.lang))
+	_, err := parser.Parse(strings.NewReader(strings.Join(cmds, "\n")), "")
+	return errors.WithStack(err)
+}
+
+func execSingle(ctx context.Context, base *Base, sh, cmd string) (*Result, error) {
+	var capture *sessionCapture
+	if base.Session != nil {
+		var err error
+		capture, err = newSessionCapture()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = capture.Close() }()
+
+		cmd = capture.Script(cmd)
+	}
+
+	c := base.command(ctx, sh, "-c", cmd)
+	startEnv := c.Env
+	if startEnv == nil {
+		startEnv = os.Environ()
+	}
+	if capture != nil {
+		// The session is captured by writing to files.
+		c.sandboxPaths(false, capture.tmpDir)
+	}
+
+	result, err := c.Run()
+
+	if capture != nil {
+		// Update the session even if the command failed
+		// as it might have changed the environment before.
+		if uErr := capture.Update(base.Session, startEnv); uErr != nil && err == nil {
+			err = uErr
+		}
+	}
 
-	if len(name) == 0 {
-		return errors.Wrapf(err, "failed to run command")
+	if len(base.Name) == 0 {
+		return result, errors.Wrapf(err, "failed to run command")
 	}
 
-	return errors.Wrapf(err, "failed to run command %q", name)
+	return result, errors.Wrapf(err, "failed to run command %q", base.Name)
 }
@@ -1,30 +1,325 @@
 package runner
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/stateful/runme/internal/document"
+	"github.com/stateful/runme/internal/renderer/cmark"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPrepareScript(t *testing.T) {
-	script := prepareScript([]string{
-		`# macOS`,
-		`brew bundle --no-lock`,
-		`brew upgrade`,
-	})
-	assert.Equal(t, "set -e -o pipefail;brew bundle --no-lock;brew upgrade;\n", script)
-
-	script = prepareScript([]string{
-		"deno install \\",
-		"--allow-read --allow-write \\",
-		"--allow-env --allow-net --allow-run \\",
-		"--no-check \\",
-		"-r -f https://deno.land/x/deploy/deployctl.ts",
-	})
-	assert.Equal(t, "set -e -o pipefail;deno install --allow-read --allow-write --allow-env --allow-net --allow-run --no-check -r -f https://deno.land/x/deploy/deployctl.ts;\n", script)
-
-	script = prepareScript([]string{
-		`pipenv run bash -c 'echo "Some message"'`,
-	})
-	assert.Equal(t, "set -e -o pipefail;pipenv run bash -c \"echo \\\"Some message\\\"\";\n", script)
+	testCases := []struct {
+		name     string
+		cmds     []string
+		expected string
+	}{
+		{
+			name:     "Empty",
+			cmds:     nil,
+			expected: "set -e -o pipefail\n",
+		},
+		{
+			name:     "Comments",
+			cmds:     []string{`# macOS`, `brew bundle --no-lock`, `brew upgrade # upgrade all`},
+			expected: "set -e -o pipefail\n# macOS\nbrew bundle --no-lock\nbrew upgrade # upgrade all\n",
+		},
+		{
+			name: "ContinuedLines",
+			cmds: []string{
+				"deno install \\",
+				"--allow-read --allow-write \\",
+				"--allow-env --allow-net --allow-run \\",
+				"--no-check \\",
+				"-r -f https://deno.land/x/deploy/deployctl.ts",
+			},
+			expected: "set -e -o pipefail\n" +
+				"deno install \\\n" +
+				"--allow-read --allow-write \\\n" +
+				"--allow-env --allow-net --allow-run \\\n" +
+				"--no-check \\\n" +
+				"-r -f https://deno.land/x/deploy/deployctl.ts\n",
+		},
+		{
+			name:     "NestedQuotes",
+			cmds:     []string{`pipenv run bash -c 'echo "Some message"'`},
+			expected: "set -e -o pipefail\npipenv run bash -c 'echo \"Some message\"'\n",
+		},
+		{
+			name:     "Backticks",
+			cmds:     []string{"echo `pwd`"},
+			expected: "set -e -o pipefail\necho `pwd`\n",
+		},
+		{
+			name:     "Heredoc",
+			cmds:     []string{"cat <<EOF > out.txt", "hello $USER", "  `date`", "", "EOF", "echo done"},
+			expected: "set -e -o pipefail\ncat <<EOF > out.txt\nhello $USER\n  `date`\n\nEOF\necho done\n",
+		},
+		{
+			name:     "IndentedHeredoc",
+			cmds:     []string{"cat <<-EOF", "\tindented", "\tEOF"},
+			expected: "set -e -o pipefail\ncat <<-EOF\n\tindented\n\tEOF\n",
+		},
+		{
+			name:     "If",
+			cmds:     []string{"if [ -f x ]; then", "  echo yes", "else", "  echo no", "fi"},
+			expected: "set -e -o pipefail\nif [ -f x ]; then\n  echo yes\nelse\n  echo no\nfi\n",
+		},
+		{
+			name:     "Lists",
+			cmds:     []string{"echo a;echo b", "", "", "echo c && echo d || echo e"},
+			expected: "set -e -o pipefail\necho a;echo b\n\n\necho c && echo d || echo e\n",
+		},
+		{
+			name:     "SyntaxError",
+			cmds:     []string{"echo 'unterminated", "if true; then"},
+			expected: "set -e -o pipefail\necho 'unterminated\nif true; then\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, prepareScript(tc.cmds, "/bin/bash"))
+		})
+	}
+}
+
+func TestPrepareScript_Dialects(t *testing.T) {
+	testCases := []struct {
+		sh       string
+		cmds     []string
+		expected string
+	}{
+		{"/bin/bash", []string{"echo a"}, "set -e -o pipefail\necho a\n"},
+		{"bash.exe", []string{"echo a"}, "set -e -o pipefail\necho a\n"},
+		{"/usr/bin/zsh", []string{"echo ${(U)name}"}, "set -e -o pipefail\necho ${(U)name}\n"},
+		{"/bin/mksh", []string{"echo a"}, "set -e -o pipefail\necho a\n"},
+		{"/bin/sh", []string{"echo a"}, "set -e\necho a\n"},
+		{"/bin/dash", []string{"echo a"}, "set -e\necho a\n"},
+		{"/usr/bin/fish", []string{"echo a; and echo b"}, "echo a; and echo b\n"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, prepareScript(tc.cmds, tc.sh), "%s: %v", tc.sh, tc.cmds)
+	}
+}
+
+func TestValidateScript(t *testing.T) {
+	testCases := []struct {
+		sh   string
+		cmds []string
+		err  string
+	}{
+		{sh: "/bin/bash", cmds: []string{"cat <<EOF", "`date`", "EOF", "[[ -n $X ]] && arr=(1 2)"}},
+		{sh: "/bin/bash", cmds: []string{"echo 'unterminated"}, err: "reached EOF without closing quote"},
+		{sh: "/bin/bash", cmds: []string{"if true; then", "echo a"}, err: `must end with "fi"`},
+		{sh: "/bin/sh", cmds: []string{"echo ${arr[@]:1}"}, err: "bash"},
+		// zsh and fish are not validated.
+		{sh: "/usr/bin/zsh", cmds: []string{"echo ${(U)name}"}},
+		{sh: "/usr/bin/fish", cmds: []string{"if true; echo a; end"}},
+	}
+
+	for _, tc := range testCases {
+		err := validateScript(tc.cmds, tc.sh)
+		if tc.err == "" {
+			assert.NoError(t, err, "%s: %v", tc.sh, tc.cmds)
+		} else {
+			assert.ErrorContains(t, err, tc.err, "%s: %v", tc.sh, tc.cmds)
+		}
+	}
+}
+
+func TestShell_lookPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	bash, err := exec.LookPath("bash")
+	require.NoError(t, err)
+	sh, err := exec.LookPath("sh")
+	require.NoError(t, err)
+
+	t.Setenv("SHELL", "/my/shell")
+
+	testCases := []struct {
+		language    string
+		interpreter string
+		expected    string
+		err         string
+	}{
+		{language: "bash", expected: bash},
+		{language: "sh", expected: sh},
+		{language: "shell", expected: "/my/shell"},
+		{language: "bat", expected: "/my/shell"},
+		{language: "", expected: "/my/shell"},
+		{language: "zsh", interpreter: "bash", expected: bash},
+		{language: "sh", interpreter: bash, expected: bash},
+		{language: "sh", interpreter: "no-such-shell", err: `exec: "no-such-shell": executable file not found in $PATH`},
+	}
+
+	for _, tc := range testCases {
+		shell := &Shell{Base: &Base{}, Language: tc.language, Interpreter: tc.interpreter}
+		path, err := shell.lookPath()
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, "language %q, interpreter %q", tc.language, tc.interpreter)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, path, "language %q, interpreter %q", tc.language, tc.interpreter)
+	}
+
+	// zsh falls back to bash.
+	if _, err := exec.LookPath("zsh"); err != nil {
+		path, err := (&Shell{Base: &Base{}, Language: "zsh"}).lookPath()
+		require.NoError(t, err)
+		assert.Equal(t, bash, path)
+	}
+
+	if _, err := exec.LookPath("fish"); err != nil {
+		_, err := (&Shell{Base: &Base{}, Language: "fish"}).lookPath()
+		assert.EqualError(t, err, "no shell found for fish blocks, tried: fish")
+	}
+}
+
+func TestShell_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	testCases := []struct {
+		name     string
+		sh       string
+		cmds     []string
+		expected string
+		fails    bool
+	}{
+		{
+			name:     "Heredoc",
+			sh:       "bash",
+			cmds:     []string{"NAME=world", "cat <<EOF", "hello $NAME", "`echo from` $(echo subshell)", "EOF"},
+			expected: "hello world\nfrom subshell\n",
+		},
+		{
+			name:     "IndentedHeredoc",
+			sh:       "bash",
+			cmds:     []string{"cat <<-EOF", "\tindented `echo backticks`", "\tEOF"},
+			expected: "indented backticks\n",
+		},
+		{
+			name:     "QuotedHeredoc",
+			sh:       "bash",
+			cmds:     []string{"cat <<'EOF'", "$HOME `pwd`", "EOF"},
+			expected: "$HOME `pwd`\n",
+		},
+		{
+			name:     "Compound",
+			sh:       "bash",
+			cmds:     []string{"for i in 1 2; do", "  if [ $i -eq 2 ]; then", "    echo two", "  else", "    echo one", "  fi", "done"},
+			expected: "one\ntwo\n",
+		},
+		{
+			name:     "Comments",
+			sh:       "bash",
+			cmds:     []string{"# first", "echo a # not printed", "echo '#' b"},
+			expected: "a\n# b\n",
+		},
+		{
+			name:     "ExitOnError",
+			sh:       "bash",
+			cmds:     []string{"echo before", "false", "echo after"},
+			expected: "before\n",
+			fails:    true,
+		},
+		{
+			name:     "Pipefail",
+			sh:       "bash",
+			cmds:     []string{"false | cat", "echo after"},
+			expected: "",
+			fails:    true,
+		},
+		{
+			name:     "POSIXShell",
+			sh:       "sh",
+			cmds:     []string{"echo before", "false", "echo after"},
+			expected: "before\n",
+			fails:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			sh, err := exec.LookPath(tc.sh)
+			if err != nil {
+				t.Skipf("%s not found", tc.sh)
+			}
+			t.Setenv("SHELL", sh)
+
+			var stdout bytes.Buffer
+
+			shell := &Shell{Base: &Base{Stdout: &stdout, Stderr: &stdout}, Cmds: tc.cmds}
+			_, err = shell.Run(context.Background())
+			if tc.fails {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, stdout.String())
+		})
+	}
+}
+
+func TestShell_RunBlock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found")
+	}
+
+	data := []byte("```bash\n" +
+		"$ cd $PWD/..\n" +
+		"cat <<-EOF\n" +
+		"\t  indented  |\n" +
+		"\tEOF\n" +
+		"```\n\n" +
+		"```bash\n" +
+		"if true; then\n" +
+		"  echo unterminated\n" +
+		"```\n")
+	node, _, err := document.New(data, cmark.Render).Parse()
+	require.NoError(t, err)
+	blocks := document.CollectCodeBlocks(node)
+	require.Len(t, blocks, 2)
+
+	dir := t.TempDir()
+
+	var stdout, dryRun bytes.Buffer
+
+	executable, err := NewRegistry().New(&Base{Dir: filepath.Join(dir, "sub"), Stdout: &stdout}, blocks[0], "")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o700))
+
+	executable.DryRun(context.Background(), &dryRun)
+	assert.Contains(t, dryRun.String(), "cd $PWD/..\ncat <<-EOF\n\t  indented  |\n\tEOF\n")
+
+	_, err = executable.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "  indented  |\n", stdout.String())
+
+	stdout.Reset()
+
+	executable, err = NewRegistry().New(&Base{Stdout: &stdout}, blocks[1], "")
+	require.NoError(t, err)
+	_, err = executable.Run(context.Background())
+	assert.ErrorContains(t, err, "failed to parse script")
+	assert.Empty(t, stdout.String())
 }
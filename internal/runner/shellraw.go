@@ -34,11 +34,11 @@ func (s *ShellRaw) DryRun(ctx context.Context, w io.Writer) {
 	}
 }
 
-func (s *ShellRaw) Run(ctx context.Context) error {
+func (s *ShellRaw) Run(ctx context.Context) (*Result, error) {
 	sh, ok := os.LookupEnv("SHELL")
 	if !ok {
 		sh = "/bin/sh"
 	}
 
-	return execSingle(ctx, sh, s.Dir, strings.Join(s.Cmds, "\n"), s.Name, s.Stdin, s.Stdout, s.Stderr)
+	return execSingle(ctx, s.Base, sh, strings.Join(s.Cmds, "\n"))
 }
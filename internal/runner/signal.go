@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultGracePeriod is the time given to a command to exit
+// after it was signaled before it's killed.
+const DefaultGracePeriod = 10 * time.Second
+
+type cancelSignalKey struct{}
+
+type cancelSignal struct {
+	mu  sync.Mutex
+	sig os.Signal
+}
+
+// WithCancelSignal returns a copy of ctx and a function which cancels it.
+// The signal passed to the function is forwarded to process groups of
+// commands run with the returned context. If nil, SIGTERM is sent.
+func WithCancelSignal(parent context.Context) (context.Context, func(os.Signal)) {
+	holder := &cancelSignal{}
+	ctx, cancel := context.WithCancel(context.WithValue(parent, cancelSignalKey{}, holder))
+	return ctx, func(sig os.Signal) {
+		holder.mu.Lock()
+		if holder.sig == nil {
+			holder.sig = sig
+		}
+		holder.mu.Unlock()
+		cancel()
+	}
+}
+
+// signalFromContext returns a signal which
+// should be sent to commands when ctx is done.
+func signalFromContext(ctx context.Context) os.Signal {
+	if holder, ok := ctx.Value(cancelSignalKey{}).(*cancelSignal); ok {
+		holder.mu.Lock()
+		defer holder.mu.Unlock()
+		if holder.sig != nil {
+			return holder.sig
+		}
+	}
+	return syscall.SIGTERM
+}
+
+// SignalError is returned when a command was terminated by a signal.
+type SignalError struct {
+	Signal syscall.Signal
+}
+
+func (e *SignalError) Error() string {
+	return "terminated by " + signalName(e.Signal)
+}
+
+// ExitCode returns the exit code as reported by shells.
+func (e *SignalError) ExitCode() int {
+	return 128 + int(e.Signal)
+}
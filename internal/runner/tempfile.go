@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// runSourceFile writes source to filename in a new temporary directory
+// and runs the executable with args followed by the path to that file.
+// The command is executed in the base's directory.
+func runSourceFile(ctx context.Context, base *Base, executable string, args []string, filename, source string) (*Result, error) {
+	tmpDir, err := os.MkdirTemp("", "runme-*")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a temp dir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sourceFile := filepath.Join(tmpDir, filename)
+
+	err = os.WriteFile(sourceFile, []byte(source), 0o600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write source to file")
+	}
+
+	cmd := base.command(ctx, executable, append(args, sourceFile)...)
+	cmd.sandboxPaths(true, tmpDir)
+	return cmd.Run()
+}
@@ -0,0 +1,173 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// terminalDrainTimeout is how long the output of a terminal is read
+// after the command exits. Processes started in background by the command
+// can keep the terminal open and the output would never end.
+const terminalDrainTimeout = 500 * time.Millisecond
+
+// terminalPollTimeout is how often, in milliseconds, copying stdin
+// to a terminal checks whether it should stop.
+const terminalPollTimeout = 50
+
+// terminal is a pseudo-terminal connected to a command.
+type terminal struct {
+	ptmx     *os.File
+	tty      *os.File
+	stdin    *os.File
+	oldState *term.State
+	output   chan struct{}
+	// stop is closed after the command exited to stop
+	// copying stdin, if it's a file, and input is closed
+	// when the copying is done.
+	stop   chan struct{}
+	input  chan struct{}
+	closed bool
+}
+
+// openTerminal opens a pseudo-terminal of the given size and sets it as
+// the command's standard streams and controlling terminal. The command
+// becomes a leader of a new session and process group.
+func openTerminal(c *exec.Cmd, size TerminalSize) (*terminal, error) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open pseudo-terminal")
+	}
+
+	if err := pty.Setsize(ptmx, &pty.Winsize{Cols: uint16(size.Cols), Rows: uint16(size.Rows)}); err != nil {
+		_ = ptmx.Close()
+		_ = tty.Close()
+		return nil, errors.Wrap(err, "failed to set pseudo-terminal size")
+	}
+
+	c.Stdin = tty
+	c.Stdout = tty
+	c.Stderr = tty
+	c.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+
+	return &terminal{ptmx: ptmx, tty: tty}, nil
+}
+
+// start copies stdin to the terminal and the terminal's output to stdout.
+// It must be called after the command started. If stdin is a terminal,
+// it's switched to raw mode so that keys, like Ctrl+C, are interpreted
+// by the pseudo-terminal.
+func (t *terminal) start(stdin io.Reader, stdout io.Writer) {
+	// The command has its own copy of the tty.
+	_ = t.tty.Close()
+
+	t.output = make(chan struct{})
+
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if state, err := term.MakeRaw(int(f.Fd())); err == nil {
+			t.stdin, t.oldState = f, state
+		}
+	}
+
+	if f, ok := stdin.(*os.File); ok {
+		t.stop = make(chan struct{})
+		t.input = make(chan struct{})
+		go func() {
+			defer close(t.input)
+			t.copyFile(f)
+		}()
+	} else if stdin != nil {
+		// Other readers can't be polled. The copy
+		// ends when the terminal is closed.
+		go func() { _, _ = io.Copy(t.ptmx, stdin) }()
+	}
+
+	go func() {
+		defer close(t.output)
+		if stdout == nil {
+			stdout = io.Discard
+		}
+		// Reading from the terminal returns EIO when it's closed.
+		_, _ = io.Copy(stdout, t.ptmx)
+	}()
+}
+
+// copyFile copies f to the terminal until stop is closed. f is polled
+// before each read so that input following the command's exit,
+// for example, a key pressed at the next prompt, is not consumed.
+func (t *terminal) copyFile(f *os.File) {
+	fd := int(f.Fd())
+	buf := make([]byte, 32*1024)
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		default:
+		}
+
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, terminalPollTimeout)
+		if err == unix.EINTR || err == nil && n == 0 {
+			continue
+		}
+		if err != nil || fds[0].Revents&(unix.POLLIN|unix.POLLHUP) == 0 {
+			return
+		}
+
+		n, err = f.Read(buf)
+		if n > 0 {
+			if _, wErr := t.ptmx.Write(buf[:n]); wErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// close waits for the remaining output, closes the terminal,
+// and restores the state of stdin. It must be called after
+// the command exited. Subsequent calls are no-op.
+func (t *terminal) close() error {
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+
+	if t.output == nil {
+		// The command has not started.
+		_ = t.tty.Close()
+	} else {
+		select {
+		case <-t.output:
+		case <-time.After(terminalDrainTimeout):
+		}
+	}
+
+	if t.stop != nil {
+		close(t.stop)
+		<-t.input
+	}
+
+	err := t.ptmx.Close()
+
+	if t.oldState != nil {
+		if rErr := term.Restore(int(t.stdin.Fd()), t.oldState); err == nil {
+			err = rErr
+		}
+	}
+
+	return errors.Wrap(err, "failed to close pseudo-terminal")
+}
@@ -0,0 +1,86 @@
+//go:build !windows
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellRaw_Terminal(t *testing.T) {
+	var stdout bytes.Buffer
+
+	shell := &ShellRaw{
+		Base: &Base{
+			Stdin:    strings.NewReader("hello\n"),
+			Stdout:   &stdout,
+			Terminal: &TerminalSize{Cols: 100, Rows: 30},
+			TailSize: 1024,
+		},
+		Cmds: []string{
+			"[ -t 0 ] && [ -t 1 ] && echo tty",
+			"stty size",
+			"read -r line && echo got $line",
+			"echo error >&2",
+			"exit 2",
+		},
+	}
+
+	result, err := shell.Run(context.Background())
+	require.Error(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, 2, result.ExitCode)
+
+	output := stdout.String()
+	assert.Contains(t, output, "tty\r\n")
+	assert.Contains(t, output, "30 100\r\n")
+	assert.Contains(t, output, "got hello\r\n")
+	assert.Contains(t, output, "error\r\n")
+	assert.Equal(t, output, string(result.Stdout))
+	assert.Empty(t, result.Stderr)
+}
+
+func TestShellRaw_TerminalStdin(t *testing.T) {
+	stdinR, stdinW, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() { _ = stdinR.Close() }()
+	defer func() { _ = stdinW.Close() }()
+
+	shell := &ShellRaw{
+		Base: &Base{
+			Stdin:    stdinR,
+			Stdout:   io.Discard,
+			Terminal: &TerminalSize{Cols: 100, Rows: 30},
+		},
+		Cmds: []string{"true"},
+	}
+
+	_, err = shell.Run(context.Background())
+	require.NoError(t, err)
+
+	// Input following the command is left for the next reader.
+	_, err = stdinW.Write([]byte("next\n"))
+	require.NoError(t, err)
+
+	read := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 16)
+		n, _ := stdinR.Read(buf)
+		read <- string(buf[:n])
+	}()
+
+	select {
+	case data := <-read:
+		assert.Equal(t, "next\n", data)
+	case <-time.After(time.Second):
+		t.Fatal("input was consumed by the terminal")
+	}
+}
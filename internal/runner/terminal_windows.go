@@ -0,0 +1,18 @@
+package runner
+
+import (
+	"io"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+type terminal struct{}
+
+func openTerminal(c *exec.Cmd, size TerminalSize) (*terminal, error) {
+	return nil, errors.New("pseudo-terminals are not supported on Windows")
+}
+
+func (t *terminal) start(stdin io.Reader, stdout io.Writer) {}
+
+func (t *terminal) close() error { return nil }
@@ -0,0 +1,114 @@
+// Package sandbox runs commands isolated from the system using
+// Linux namespaces. The command sees the system directories, like /usr
+// and /etc, read-only, and the project directory with a writable overlay
+// whose changes are discarded. The home directory and /tmp are empty
+// and writable. Optionally, the network is not available.
+//
+// A sandboxed command is started by re-executing the current binary
+// which sets up the namespaces and runs the command. The program
+// must call Init at the very beginning to support it.
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// SetupFailedExitCode is the exit code of a sandboxed command
+// when the sandbox could not be set up.
+const SetupFailedExitCode = 125
+
+// configEnv is set to the path of the config file
+// in processes started by Wrap.
+const configEnv = "RUNME_SANDBOX_CONFIG"
+
+// hostEnv lists variables of the current process's
+// environment passed to sandboxed commands.
+var hostEnv = []string{"PATH", "HOME", "TERM", "LANG"}
+
+// Options configure a sandbox.
+type Options struct {
+	// ProjectDir is visible read-only with a writable overlay.
+	ProjectDir string `json:"projectDir"`
+	// Network, if true, allows access to the network.
+	Network bool `json:"network"`
+	// ReadOnly lists additional paths visible read-only.
+	ReadOnly []string `json:"readOnly,omitempty"`
+	// Writable lists additional paths which are writable
+	// and whose changes are kept.
+	Writable []string `json:"writable,omitempty"`
+}
+
+// Environ returns the environment sandboxed commands start from.
+// Only PATH, HOME, TERM, and LANG of the current process are kept
+// so that secrets, like tokens, are not exposed to the commands.
+func Environ() []string {
+	var env []string
+	for _, key := range hostEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
+
+// config is passed to the process setting up the sandbox.
+type config struct {
+	Options
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+	Dir  string   `json:"dir"`
+	// Scratch is a temporary directory for
+	// the sandbox's root and overlays.
+	Scratch string `json:"scratch"`
+}
+
+func writeConfig(cfg config) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	path := filepath.Join(cfg.Scratch, "config.json")
+	err = os.WriteFile(path, data, 0o600)
+	return path, errors.Wrap(err, "failed to write sandbox config")
+}
+
+func readConfig(path string) (config, error) {
+	var cfg config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, errors.Wrap(err, "failed to read sandbox config")
+	}
+
+	err = json.Unmarshal(data, &cfg)
+	return cfg, errors.Wrap(err, "invalid sandbox config")
+}
+
+// Init sets up the sandbox and runs the command, if the current process
+// was started by a command prepared with Wrap. In such case, it exits
+// with the command's exit code and never returns. Otherwise, it's no-op.
+func Init() {
+	path, ok := os.LookupEnv(configEnv)
+	if !ok {
+		return
+	}
+	_ = os.Unsetenv(configEnv)
+
+	cfg, err := readConfig(path)
+	if err == nil {
+		var code int
+		code, err = run(cfg)
+		if err == nil {
+			os.Exit(code)
+		}
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "runme: sandbox: %s\n", err)
+	os.Exit(SetupFailedExitCode)
+}
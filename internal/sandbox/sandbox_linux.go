@@ -0,0 +1,553 @@
+package sandbox
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+// systemDirs are visible read-only in the sandbox if they exist.
+var systemDirs = []string{
+	"/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/usr", "/etc", "/opt", "/var", "/sys", "/nix",
+}
+
+// devices are bound from the host's /dev.
+var devices = []string{"null", "zero", "full", "random", "urandom", "tty"}
+
+// Wrap changes c, which must not have been started, to run in a sandbox.
+// If c.Env is nil, the command gets the environment returned by Environ.
+// The returned function removes temporary files of the sandbox
+// and must be called after the command exits.
+func Wrap(c *exec.Cmd, opts Options) (func(), error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the runme executable")
+	}
+
+	scratch, err := os.MkdirTemp("", "runme-sandbox-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a temp dir")
+	}
+	cleanup := func() { _ = os.RemoveAll(scratch) }
+
+	cfgPath, err := writeConfig(config{
+		Options: opts,
+		Path:    c.Path,
+		Args:    c.Args,
+		Dir:     c.Dir,
+		Scratch: scratch,
+	})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	env := c.Env
+	if env == nil {
+		env = Environ()
+	}
+	c.Env = append(env[:len(env):len(env)], configEnv+"="+cfgPath)
+
+	c.Path = self
+	c.Args = []string{os.Args[0]}
+
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := c.SysProcAttr
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+	if !opts.Network {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	// The process setting up the sandbox needs to be root in the new user
+	// namespace to mount file systems. It's mapped to the current user.
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+	// Unless the command runs in a pseudo-terminal, it leads a new session
+	// without a controlling terminal so that it can't inject input into
+	// the terminal of runme.
+	if !attr.Setctty {
+		attr.Setsid = true
+		attr.Setpgid = false
+		attr.Foreground = false
+	}
+	// The sandbox doesn't outlive runme, even if it's killed.
+	attr.Pdeathsig = syscall.SIGKILL
+
+	return cleanup, nil
+}
+
+// StartError explains why a sandboxed command failed to start.
+func StartError(err error) error {
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EINVAL) {
+		return errors.Wrap(err, "failed to create the sandbox; make sure that unprivileged user namespaces are enabled")
+	}
+	return errors.Wrap(err, "failed to start the sandbox")
+}
+
+// run sets up the sandbox and runs the command in it. It's called
+// in the process started by Wrap which is the init process of
+// the new PID namespace.
+func run(cfg config) (int, error) {
+	projectWritable, err := setupRoot(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	if !cfg.Network {
+		// Only the loopback interface is available.
+		_ = loopbackUp()
+	}
+
+	if cfg.Dir != "" {
+		if _, err := os.Stat(cfg.Dir); err != nil {
+			return 0, errors.Errorf("working directory %s is not available in the sandbox", cfg.Dir)
+		}
+	}
+
+	detector := newViolationDetector(os.Stderr, cfg.Network, projectWritable)
+
+	c := exec.Command(cfg.Path)
+	c.Args = cfg.Args
+	c.Dir = cfg.Dir
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = detector
+
+	// Signals are sent to the whole process group which includes
+	// the command. They are only caught here as otherwise
+	// the init process would exit before the command.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+	go func() {
+		for range signals {
+		}
+	}()
+
+	if err := c.Start(); err != nil {
+		return 0, errors.Wrapf(err, "failed to start %s", cfg.Path)
+	}
+
+	_ = c.Wait()
+
+	detector.Report(os.Stderr)
+
+	status, ok := c.ProcessState.Sys().(syscall.WaitStatus)
+	if ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return c.ProcessState.ExitCode(), nil
+}
+
+// mountSpec is a mount done after the system directories
+// are mounted. Mounts are done from the shortest target
+// so that nested ones are not hidden.
+type mountSpec struct {
+	target string
+	mount  func(target string) error
+}
+
+// setupRoot mounts the sandbox's root and changes to it. It reports
+// whether changes to the project directory are allowed which is not
+// the case if overlays are not supported.
+func setupRoot(cfg config) (projectWritable bool, _ error) {
+	// Mounts must not propagate to the host.
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return false, errors.Wrap(err, "failed to make mounts private")
+	}
+
+	if err := syscall.Mount("tmpfs", cfg.Scratch, "tmpfs", 0, "mode=0755"); err != nil {
+		return false, errors.Wrap(err, "failed to mount scratch space")
+	}
+
+	root := filepath.Join(cfg.Scratch, "root")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		return false, errors.WithStack(err)
+	}
+	// The new root must be a mount point.
+	if err := syscall.Mount(root, root, "", syscall.MS_BIND, ""); err != nil {
+		return false, errors.Wrap(err, "failed to mount root")
+	}
+
+	for _, dir := range systemDirs {
+		if err := bindSystemDir(root, dir); err != nil {
+			return false, err
+		}
+	}
+
+	if err := setupDev(root); err != nil {
+		return false, err
+	}
+
+	if err := setupProc(root); err != nil {
+		return false, err
+	}
+
+	tmpfs := func(target string) error {
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return errors.WithStack(err)
+		}
+		return errors.Wrapf(syscall.Mount("tmpfs", target, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0777"), "failed to mount %s", target)
+	}
+
+	specs := []mountSpec{
+		{target: "/tmp", mount: tmpfs},
+		{target: "/run", mount: tmpfs},
+	}
+
+	if home, err := os.UserHomeDir(); err == nil && home != "/" && !isWithin(home, cfg.ProjectDir) {
+		specs = append(specs, mountSpec{target: home, mount: tmpfs})
+	}
+
+	if cfg.ProjectDir != "" {
+		specs = append(specs, mountSpec{
+			target: cfg.ProjectDir,
+			mount: func(target string) (err error) {
+				projectWritable, err = mountOverlay(cfg.ProjectDir, target, cfg.Scratch)
+				return err
+			},
+		})
+	}
+
+	for _, path := range cfg.ReadOnly {
+		path := path
+		specs = append(specs, mountSpec{target: path, mount: func(target string) error { return bind(path, target, true) }})
+	}
+	for _, path := range cfg.Writable {
+		path := path
+		specs = append(specs, mountSpec{target: path, mount: func(target string) error { return bind(path, target, false) }})
+	}
+
+	// The executable might be installed outside the system directories.
+	if !isVisible(cfg.Path) {
+		specs = append(specs, mountSpec{target: cfg.Path, mount: func(target string) error { return bind(cfg.Path, target, true) }})
+	}
+
+	sort.SliceStable(specs, func(i, j int) bool { return len(specs[i].target) < len(specs[j].target) })
+
+	for _, spec := range specs {
+		target := filepath.Join(root, spec.target)
+		if err := spec.mount(target); err != nil {
+			return false, err
+		}
+	}
+
+	return projectWritable, pivotRoot(root)
+}
+
+// isVisible reports whether path is within one of the system directories.
+func isVisible(path string) bool {
+	for _, dir := range systemDirs {
+		if isWithin(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWithin(path, dir string) bool {
+	return dir != "" && (path == dir || strings.HasPrefix(path, dir+"/"))
+}
+
+func bindSystemDir(root, dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return nil
+	}
+
+	target := filepath.Join(root, dir)
+
+	// For example, /bin is a link to /usr/bin on many distributions.
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(dir)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return errors.WithStack(os.Symlink(link, target))
+	}
+
+	return bind(dir, target, true)
+}
+
+// bind makes src visible at target. If readOnly is true,
+// target and all mounts under it are read-only.
+func bind(src, target string, readOnly bool) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if info.IsDir() {
+		err = os.MkdirAll(target, 0o755)
+	} else {
+		err = createFile(target)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := syscall.Mount(src, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return errors.Wrapf(err, "failed to bind %s", src)
+	}
+
+	if !readOnly {
+		return nil
+	}
+
+	mounts, err := mountPointsUnder(target)
+	if err != nil {
+		return err
+	}
+	for _, path := range mounts {
+		if err := remountReadOnly(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Flags of statfs(2) mapped to flags of mount(2). They are locked
+// in a user namespace and must be kept when remounting.
+var lockedMountFlags = map[int64]uintptr{
+	0x0002: syscall.MS_NOSUID,     // ST_NOSUID
+	0x0004: syscall.MS_NODEV,      // ST_NODEV
+	0x0008: syscall.MS_NOEXEC,     // ST_NOEXEC
+	0x0400: syscall.MS_NOATIME,    // ST_NOATIME
+	0x0800: syscall.MS_NODIRATIME, // ST_NODIRATIME
+	0x1000: syscall.MS_RELATIME,   // ST_RELATIME
+}
+
+func remountReadOnly(path string) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return errors.Wrapf(err, "failed to stat %s", path)
+	}
+
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY)
+	for stFlag, msFlag := range lockedMountFlags {
+		if st.Flags&stFlag != 0 {
+			flags |= msFlag
+		}
+	}
+
+	err := syscall.Mount("", path, "", flags, "")
+	return errors.Wrapf(err, "failed to make %s read-only", path)
+}
+
+// mountPointsUnder returns mount points of the current mount
+// namespace which are dir or are under dir, from the shortest.
+func mountPointsUnder(dir string) ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var result []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		path := unescapeMountInfo(fields[4])
+		if isWithin(path, dir) {
+			result = append(result, path)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return len(result[i]) < len(result[j]) })
+
+	return result, errors.WithStack(scanner.Err())
+}
+
+// unescapeMountInfo decodes octal escapes, like \040 for a space,
+// used in /proc/self/mountinfo.
+func unescapeMountInfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				_ = b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		_ = b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// mountOverlay makes lower visible at target with a writable layer
+// in the scratch space. If overlays are not supported, lower is
+// read-only and the returned writable is false.
+func mountOverlay(lower, target, scratch string) (writable bool, _ error) {
+	upper := filepath.Join(scratch, "upper")
+	work := filepath.Join(scratch, "work")
+
+	for _, dir := range []string{target, upper, work} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return false, errors.WithStack(err)
+		}
+	}
+
+	data := fmt.Sprintf(
+		"lowerdir=%s,upperdir=%s,workdir=%s",
+		escapeOverlay(lower), escapeOverlay(upper), escapeOverlay(work),
+	)
+
+	if err := syscall.Mount("overlay", target, "overlay", 0, data); err != nil {
+		// Overlays in user namespaces require Linux 5.11.
+		// Fall back to read-only access.
+		_, _ = fmt.Fprintf(os.Stderr, "runme: sandbox: overlays are not supported, the project directory is read-only: %s\n", err)
+		return false, bind(lower, target, true)
+	}
+
+	return true, nil
+}
+
+func escapeOverlay(path string) string {
+	return strings.NewReplacer(`\`, `\\`, `,`, `\,`, `:`, `\:`).Replace(path)
+}
+
+// setupDev creates a minimal /dev with the common devices and terminals.
+func setupDev(root string) error {
+	dev := filepath.Join(root, "dev")
+
+	if err := os.Mkdir(dev, 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := syscall.Mount("tmpfs", dev, "tmpfs", syscall.MS_NOSUID, "mode=0755"); err != nil {
+		return errors.Wrap(err, "failed to mount /dev")
+	}
+
+	for _, name := range devices {
+		if _, err := os.Stat(filepath.Join("/dev", name)); err != nil {
+			continue
+		}
+		if err := bind(filepath.Join("/dev", name), filepath.Join(dev, name), false); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat("/dev/pts"); err == nil {
+		if err := bind("/dev/pts", filepath.Join(dev, "pts"), false); err != nil {
+			return err
+		}
+		if err := os.Symlink("pts/ptmx", filepath.Join(dev, "ptmx")); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(dev, "shm"), 0o1777); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for name, target := range map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	} {
+		if err := os.Symlink(target, filepath.Join(dev, name)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// setupProc mounts /proc of the new PID namespace. It fails if it's not
+// allowed, for example, in containers hiding parts of /proc, as the host's
+// one would expose other processes, including their environment.
+func setupProc(root string) error {
+	proc := filepath.Join(root, "proc")
+
+	if err := os.Mkdir(proc, 0o555); err != nil {
+		return errors.WithStack(err)
+	}
+
+	err := syscall.Mount("proc", proc, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, "")
+	return errors.Wrap(err, "failed to mount /proc")
+}
+
+func pivotRoot(root string) error {
+	oldRoot := filepath.Join(root, ".oldroot")
+
+	if err := os.Mkdir(oldRoot, 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := syscall.PivotRoot(root, oldRoot); err != nil {
+		return errors.Wrap(err, "failed to change root")
+	}
+	if err := os.Chdir("/"); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := syscall.Unmount("/.oldroot", syscall.MNT_DETACH); err != nil {
+		return errors.Wrap(err, "failed to unmount the old root")
+	}
+	if err := os.Remove("/.oldroot"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return remountReadOnly("/")
+}
+
+// loopbackUp brings up the loopback interface
+// of the new network namespace.
+func loopbackUp() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = syscall.Close(fd) }()
+
+	var req struct {
+		name  [syscall.IFNAMSIZ]byte
+		flags uint16
+		_     [22]byte
+	}
+	copy(req.name[:], "lo")
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCGIFFLAGS, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return errors.WithStack(errno)
+	}
+
+	req.flags |= syscall.IFF_UP
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return errors.WithStack(errno)
+	}
+
+	return nil
+}
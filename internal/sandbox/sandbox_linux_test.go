@@ -0,0 +1,153 @@
+package sandbox
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// The test binary sets up sandboxes started by Wrap.
+	Init()
+	os.Exit(m.Run())
+}
+
+// runSandboxed runs script with sh in a sandbox and returns its exit code and output.
+func runSandboxed(t *testing.T, opts Options, script string) (int, string) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	c := exec.Command("/bin/sh", "-c", script)
+	c.Dir = opts.ProjectDir
+	c.Stdout = &out
+	c.Stderr = &out
+
+	cleanup, err := Wrap(c, opts)
+	require.NoError(t, err)
+	defer cleanup()
+
+	err = c.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), out.String()
+	}
+	require.NoError(t, err)
+	return 0, out.String()
+}
+
+func skipIfNotSupported(t *testing.T) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	c := exec.Command("/bin/sh", "-c", "true")
+	c.Stdout = &out
+	c.Stderr = &out
+
+	cleanup, err := Wrap(c, Options{})
+	require.NoError(t, err)
+	defer cleanup()
+
+	// User namespaces might be disabled or restricted.
+	if err := c.Run(); err != nil {
+		t.Skipf("sandbox is not supported: %s: %s", err, out.String())
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	skipIfNotSupported(t)
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "README.md"), []byte("# Hello\n"), 0o644))
+
+	t.Run("ProjectDir", func(t *testing.T) {
+		code, out := runSandboxed(
+			t,
+			Options{ProjectDir: projectDir},
+			"cat README.md && echo changed > README.md && echo new > new.txt && cat README.md new.txt",
+		)
+		assert.Equal(t, 0, code, out)
+		assert.Equal(t, "# Hello\nchanged\nnew\n", out)
+
+		data, err := os.ReadFile(filepath.Join(projectDir, "README.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Hello\n", string(data))
+		assert.NoFileExists(t, filepath.Join(projectDir, "new.txt"))
+	})
+
+	t.Run("ReadOnlySystem", func(t *testing.T) {
+		code, out := runSandboxed(t, Options{ProjectDir: projectDir}, "touch /etc/runme-sandbox-test")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, out, "Read-only file system")
+		assert.Contains(t, out, "runme: sandbox: "+violationMessages[violationWrite])
+		assert.NoFileExists(t, "/etc/runme-sandbox-test")
+	})
+
+	t.Run("Home", func(t *testing.T) {
+		home, err := os.UserHomeDir()
+		require.NoError(t, err)
+
+		code, out := runSandboxed(t, Options{ProjectDir: projectDir}, `ls -A "$HOME" && touch "$HOME/x" && echo ok`)
+		assert.Equal(t, 0, code, out)
+		assert.Equal(t, "ok\n", out)
+		assert.NoFileExists(t, filepath.Join(home, "x"))
+	})
+
+	t.Run("Paths", func(t *testing.T) {
+		readOnly, writable := t.TempDir(), t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(readOnly, "in"), []byte("input\n"), 0o644))
+
+		code, out := runSandboxed(
+			t,
+			Options{ProjectDir: projectDir, ReadOnly: []string{readOnly}, Writable: []string{writable}},
+			"cat "+readOnly+"/in > "+writable+"/out",
+		)
+		assert.Equal(t, 0, code, out)
+
+		data, err := os.ReadFile(filepath.Join(writable, "out"))
+		require.NoError(t, err)
+		assert.Equal(t, "input\n", string(data))
+	})
+
+	t.Run("PID", func(t *testing.T) {
+		// Only the process setting up the sandbox and the shell are visible.
+		code, out := runSandboxed(t, Options{ProjectDir: projectDir}, "for p in /proc/[0-9]*; do echo $p; done")
+		assert.Equal(t, 0, code, out)
+		assert.Regexp(t, `^/proc/1\n/proc/\d+\n$`, out)
+	})
+
+	t.Run("Environment", func(t *testing.T) {
+		t.Setenv("RUNME_SANDBOX_TEST_TOKEN", "secret")
+
+		code, out := runSandboxed(t, Options{ProjectDir: projectDir}, `echo "${RUNME_SANDBOX_TEST_TOKEN-unset} ${PATH:+path}"`)
+		assert.Equal(t, 0, code, out)
+		assert.Equal(t, "unset path\n", out)
+	})
+
+	t.Run("Session", func(t *testing.T) {
+		// The process setting up the sandbox leads the session of the shell.
+		code, out := runSandboxed(t, Options{ProjectDir: projectDir}, "cut -d ' ' -f 6 /proc/$$/stat")
+		assert.Equal(t, 0, code, out)
+		assert.Equal(t, "1\n", out)
+	})
+
+	t.Run("Network", func(t *testing.T) {
+		code, out := runSandboxed(t, Options{ProjectDir: projectDir}, "tail -n +3 /proc/net/dev | cut -d : -f 1")
+		assert.Equal(t, 0, code, out)
+		assert.Equal(t, "lo", strings.TrimSpace(out))
+	})
+
+	t.Run("ExitCode", func(t *testing.T) {
+		code, _ := runSandboxed(t, Options{ProjectDir: projectDir}, "exit 42")
+		assert.Equal(t, 42, code)
+
+		code, _ = runSandboxed(t, Options{ProjectDir: projectDir}, "kill -TERM $$")
+		assert.Equal(t, 128+15, code)
+	})
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+// +build !linux
+
+package sandbox
+
+import (
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+var errNotSupported = errors.New("sandbox is supported only on Linux")
+
+// Wrap changes c, which must not have been started, to run in a sandbox.
+// It's supported only on Linux.
+func Wrap(c *exec.Cmd, opts Options) (func(), error) {
+	return nil, errNotSupported
+}
+
+// StartError explains why a sandboxed command failed to start.
+func StartError(err error) error {
+	return errors.Wrap(err, "failed to start the sandbox")
+}
+
+func run(cfg config) (int, error) {
+	return 0, errNotSupported
+}
@@ -0,0 +1,102 @@
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+type violation int
+
+const (
+	violationWrite violation = iota
+	violationNetwork
+	// violationReadOnlyProject is reported instead of violationWrite
+	// if the project directory is read-only.
+	violationReadOnlyProject
+)
+
+// violationPatterns are fragments of error messages printed
+// by programs when the sandbox denies an operation.
+var violationPatterns = map[violation][]string{
+	violationWrite: {
+		"Read-only file system",
+		"EROFS",
+	},
+	violationNetwork: {
+		"Network is unreachable",
+		"ENETUNREACH",
+		"Temporary failure in name resolution",
+		"EAI_AGAIN",
+		"Could not resolve host",
+		"Name or service not known",
+	},
+}
+
+var violationMessages = map[violation]string{
+	violationWrite: "the command tried to write to a read-only location; " +
+		"only the project directory, the home directory, and /tmp are writable " +
+		"and changes to them are discarded",
+	violationReadOnlyProject: "the command tried to write to a read-only location; " +
+		"only the home directory and /tmp are writable and changes to them are discarded; " +
+		"the project directory is read-only as overlays are not supported",
+	violationNetwork: "the command tried to access the network which is disabled in the sandbox",
+}
+
+// violationDetector passes through output of a sandboxed command
+// and looks for messages indicating that it was denied by the sandbox.
+type violationDetector struct {
+	w               io.Writer
+	network         bool
+	projectWritable bool
+
+	mu       sync.Mutex
+	carry    []byte
+	detected map[violation]bool
+}
+
+func newViolationDetector(w io.Writer, network, projectWritable bool) *violationDetector {
+	return &violationDetector{w: w, network: network, projectWritable: projectWritable, detected: make(map[violation]bool)}
+}
+
+// maxPatternLen is the number of bytes kept between writes
+// to detect patterns split across them.
+const maxPatternLen = 64
+
+func (d *violationDetector) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	data := append(d.carry, p...)
+	for v, patterns := range violationPatterns {
+		if v == violationNetwork && d.network {
+			continue
+		}
+		for _, pattern := range patterns {
+			if bytes.Contains(data, []byte(pattern)) {
+				d.detected[v] = true
+			}
+		}
+	}
+	if len(data) > maxPatternLen {
+		data = data[len(data)-maxPatternLen:]
+	}
+	d.carry = append(d.carry[:0], data...)
+	d.mu.Unlock()
+
+	return d.w.Write(p)
+}
+
+// Report writes explanations of detected violations to w.
+func (d *violationDetector) Report(w io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, v := range []violation{violationWrite, violationNetwork} {
+		if d.detected[v] {
+			if v == violationWrite && !d.projectWritable {
+				v = violationReadOnlyProject
+			}
+			_, _ = fmt.Fprintf(w, "runme: sandbox: %s\n", violationMessages[v])
+		}
+	}
+}
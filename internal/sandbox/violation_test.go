@@ -0,0 +1,71 @@
+package sandbox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViolationDetector(t *testing.T) {
+	testCases := []struct {
+		name            string
+		network         bool
+		projectWritable bool
+		writes          []string
+		expected        string
+	}{
+		{
+			name:   "None",
+			writes: []string{"error: no such file\n"},
+		},
+		{
+			name:            "ReadOnly",
+			projectWritable: true,
+			writes:          []string{"touch: cannot touch '/etc/x': Read-only file system\n"},
+			expected:        "runme: sandbox: " + violationMessages[violationWrite] + "\n",
+		},
+		{
+			name:     "ReadOnlyProject",
+			writes:   []string{"touch: cannot touch 'x': Read-only file system\n"},
+			expected: "runme: sandbox: " + violationMessages[violationReadOnlyProject] + "\n",
+		},
+		{
+			name:     "SplitWrites",
+			writes:   []string{"curl: (6) Could not res", "olve host: example.com\n"},
+			expected: "runme: sandbox: " + violationMessages[violationNetwork] + "\n",
+		},
+		{
+			name:    "NetworkAllowed",
+			network: true,
+			writes:  []string{"curl: (6) Could not resolve host: example.com\n"},
+		},
+		{
+			name:            "Both",
+			projectWritable: true,
+			writes:          []string{"EROFS: read-only file system, open 'x'\n", "getaddrinfo EAI_AGAIN registry.npmjs.org\n"},
+			expected: "runme: sandbox: " + violationMessages[violationWrite] + "\n" +
+				"runme: sandbox: " + violationMessages[violationNetwork] + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var out, report bytes.Buffer
+
+			d := newViolationDetector(&out, tc.network, tc.projectWritable)
+			for _, w := range tc.writes {
+				n, err := d.Write([]byte(w))
+				require.NoError(t, err)
+				assert.Equal(t, len(w), n)
+			}
+			d.Report(&report)
+
+			assert.Equal(t, strings.Join(tc.writes, ""), out.String())
+			assert.Equal(t, tc.expected, report.String())
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package trust keeps approvals of commands. A command is identified
+// by the markdown file or URL it comes from and its name. An approval
+// is valid only for the exact script which was reviewed.
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Hash returns a content hash of the script.
+func Hash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Approval records that a script of a command was approved.
+type Approval struct {
+	// Source is an absolute path or URL of the markdown file.
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Hash   string `json:"hash"`
+	// Script is kept to show what changed since the approval.
+	Script string    `json:"script"`
+	Time   time.Time `json:"time"`
+}
+
+// Store keeps approvals in a JSON file. Only the latest
+// approval of each command is kept.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// List returns all approvals sorted by source and name.
+func (s *Store) List() ([]Approval, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read approvals")
+	}
+
+	var approvals []Approval
+	if err := json.Unmarshal(data, &approvals); err != nil {
+		return nil, errors.Wrap(err, "failed to parse approvals")
+	}
+
+	sort.SliceStable(approvals, func(i, j int) bool {
+		if approvals[i].Source != approvals[j].Source {
+			return approvals[i].Source < approvals[j].Source
+		}
+		return approvals[i].Name < approvals[j].Name
+	})
+
+	return approvals, nil
+}
+
+// Get returns the approval of the command. The second return
+// value is false if the command has never been approved.
+func (s *Store) Get(source, name string) (Approval, bool, error) {
+	approvals, err := s.List()
+	if err != nil {
+		return Approval{}, false, err
+	}
+	for _, a := range approvals {
+		if a.Source == source && a.Name == name {
+			return a, true, nil
+		}
+	}
+	return Approval{}, false, nil
+}
+
+// Approve stores the approval replacing the previous one of the same command.
+func (s *Store) Approve(a Approval) error {
+	approvals, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	result := approvals[:0]
+	for _, item := range approvals {
+		if item.Source != a.Source || item.Name != a.Name {
+			result = append(result, item)
+		}
+	}
+
+	return s.write(append(result, a))
+}
+
+// Revoke removes approvals of commands from the source. If no names are
+// given, all of the source's approvals are removed. It returns
+// the number of removed approvals.
+func (s *Store) Revoke(source string, names ...string) (int, error) {
+	approvals, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := make(map[string]bool, len(names))
+	for _, name := range names {
+		revoked[name] = true
+	}
+
+	result := approvals[:0]
+	for _, a := range approvals {
+		if a.Source == source && (len(names) == 0 || revoked[a.Name]) {
+			continue
+		}
+		result = append(result, a)
+	}
+
+	n := len(approvals) - len(result)
+	if n == 0 {
+		return 0, nil
+	}
+
+	return n, s.write(result)
+}
+
+// write replaces the file atomically so that a crash
+// does not leave approvals partially written.
+func (s *Store) write(approvals []Approval) error {
+	if approvals == nil {
+		approvals = []Approval{}
+	}
+
+	data, err := json.MarshalIndent(approvals, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	dir := filepath.Dir(s.path)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return errors.Wrap(err, "failed to create approvals dir")
+	}
+
+	f, err := os.CreateTemp(dir, ".trust-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to write approvals")
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	_, err = f.Write(append(data, '\n'))
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write approvals")
+	}
+
+	return errors.Wrap(os.Rename(f.Name(), s.path), "failed to write approvals")
+}
@@ -0,0 +1,88 @@
+package trust
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Hash(""))
+	assert.NotEqual(t, Hash("echo a"), Hash("echo a "))
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runme", "trust.json")
+	store := NewStore(path)
+
+	approvals, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, approvals)
+
+	_, ok, err := store.Get("/project/README.md", "build")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	now := time.Date(2023, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.Approve(Approval{Source: "https://example.com/README.md", Name: "setup", Hash: Hash("curl"), Script: "curl", Time: now}))
+	require.NoError(t, store.Approve(Approval{Source: "/project/README.md", Name: "deploy", Hash: Hash("deploy"), Script: "deploy", Time: now}))
+	require.NoError(t, store.Approve(Approval{Source: "/project/README.md", Name: "build", Hash: Hash("make"), Script: "make", Time: now}))
+
+	// Approving a changed script replaces the previous approval.
+	require.NoError(t, store.Approve(Approval{Source: "/project/README.md", Name: "build", Hash: Hash("make all"), Script: "make all", Time: now.Add(time.Hour)}))
+
+	approvals, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, approvals, 3)
+	assert.Equal(t, "build", approvals[0].Name)
+	assert.Equal(t, "deploy", approvals[1].Name)
+	assert.Equal(t, "setup", approvals[2].Name)
+
+	a, ok, err := store.Get("/project/README.md", "build")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, Hash("make all"), a.Hash)
+	assert.Equal(t, "make all", a.Script)
+	assert.True(t, now.Add(time.Hour).Equal(a.Time))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	n, err := store.Revoke("/project/README.md", "build", "unknown")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, ok, err = store.Get("/project/README.md", "build")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	n, err = store.Revoke("/project/README.md")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = store.Revoke("/project/README.md")
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	approvals, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+	assert.Equal(t, "setup", approvals[0].Name)
+}
+
+func TestStore_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trust.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	_, err := NewStore(path).List()
+	assert.ErrorContains(t, err, "failed to parse approvals")
+}
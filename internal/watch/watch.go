@@ -0,0 +1,200 @@
+// Package watch waits for changes of files in a directory tree.
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// DefaultDebounce is how long a Watcher waits for more changes
+// before reporting them.
+const DefaultDebounce = 200 * time.Millisecond
+
+// MatchFunc reports whether a change of a file should be reported.
+// name is relative to the watched directory and uses slashes.
+type MatchFunc func(name string) bool
+
+// Watcher watches a directory and its subdirectories, including
+// those created later. Hidden directories, like .git, are skipped.
+type Watcher struct {
+	dir      string
+	debounce time.Duration
+	fsw      *fsnotify.Watcher
+}
+
+// New starts watching dir.
+func New(dir string) (*Watcher, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create watcher")
+	}
+
+	w := &Watcher{
+		dir:      dir,
+		debounce: DefaultDebounce,
+		fsw:      fsw,
+	}
+
+	if err := w.addTree(dir); err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// addTree watches root and its subdirectories.
+func (w *Watcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The directory might have been removed in the meantime.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if name != w.dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return errors.Wrapf(w.fsw.Add(name), "failed to watch %s", name)
+	})
+}
+
+// Wait blocks until a file matching match is changed and no other
+// changes happen within the debounce period. Other changes are discarded.
+// It returns ctx.Err() if ctx is done first.
+func (w *Watcher) Wait(ctx context.Context, match MatchFunc) error {
+	var timer <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+			return nil
+		case err, ok := <-w.fsw.Errors:
+			return watchError(err, ok)
+		case event, ok := <-w.fsw.Events:
+			name, err := w.handle(event, ok)
+			if err != nil {
+				return err
+			}
+			if name != "" && w.matches(match, name) {
+				timer = time.After(w.debounce)
+			}
+		}
+	}
+}
+
+// Drain discards changes until none happen within the debounce period,
+// for example, changes made by a command which has just finished.
+// It returns ctx.Err() if ctx is done first.
+func (w *Watcher) Drain(ctx context.Context) error {
+	timer := time.After(w.debounce)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+			return nil
+		case err, ok := <-w.fsw.Errors:
+			return watchError(err, ok)
+		case event, ok := <-w.fsw.Events:
+			if _, err := w.handle(event, ok); err != nil {
+				return err
+			}
+			timer = time.After(w.debounce)
+		}
+	}
+}
+
+// handle watches a new directory and returns the name of the changed
+// file. The name is empty if the change is not relevant.
+func (w *Watcher) handle(event fsnotify.Event, ok bool) (string, error) {
+	if !ok {
+		return "", errors.New("watcher closed")
+	}
+	// Editors and tools often only touch files.
+	if event.Op == fsnotify.Chmod {
+		return "", nil
+	}
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addTree(event.Name); err != nil {
+				return "", err
+			}
+		}
+	}
+	return event.Name, nil
+}
+
+func watchError(err error, ok bool) error {
+	if !ok {
+		return errors.New("watcher closed")
+	}
+	return errors.Wrap(err, "failed to watch files")
+}
+
+func (w *Watcher) matches(match MatchFunc, name string) bool {
+	rel, err := filepath.Rel(w.dir, name)
+	if err != nil {
+		return false
+	}
+	return match(filepath.ToSlash(rel))
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	return errors.WithStack(w.fsw.Close())
+}
+
+// Match reports whether name matches the glob pattern. Both use slashes.
+// A "**" element matches any number of directories. A pattern without
+// slashes is matched against the last element of name, so "*.go" matches
+// Go files in any directory.
+func Match(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchElems(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+
+	ok, _ := path.Match(pattern[0], name[0])
+	return ok && matchElems(pattern[1:], name[1:])
+}
@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/cmd/run.go", true},
+		{"*.go", "README.md", false},
+		{"gen/*.go", "gen/types.go", true},
+		{"gen/*.go", "gen/sub/types.go", false},
+		{"gen/**/*.go", "gen/types.go", true},
+		{"gen/**/*.go", "gen/sub/deep/types.go", true},
+		{"gen/**", "gen/sub/types.go", true},
+		{"**/testdata/*", "a/b/testdata/file", true},
+		{"**/testdata/*", "testdata/file", true},
+		{"src/*.ts", "other/x.ts", false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.match, Match(tc.pattern, tc.name), "%s matching %s", tc.pattern, tc.name)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".hidden"), 0o755))
+
+	w, err := New(dir)
+	require.NoError(t, err)
+	defer func() { _ = w.Close() }()
+	w.debounce = 10 * time.Millisecond
+
+	wait := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		return w.Wait(ctx, func(name string) bool { return Match("*.go", name) })
+	}
+
+	t.Run("NotMatching", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Hi"), 0o600))
+		assert.ErrorIs(t, wait(), context.DeadlineExceeded)
+	})
+
+	t.Run("Hidden", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden", "main.go"), nil, 0o600))
+		assert.ErrorIs(t, wait(), context.DeadlineExceeded)
+	})
+
+	t.Run("Matching", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), nil, 0o600))
+		assert.NoError(t, wait())
+	})
+
+	t.Run("NewDirectory", func(t *testing.T) {
+		sub := filepath.Join(dir, "sub")
+		require.NoError(t, os.Mkdir(sub, 0o755))
+		assert.ErrorIs(t, wait(), context.DeadlineExceeded)
+
+		require.NoError(t, os.WriteFile(filepath.Join(sub, "types.go"), nil, 0o600))
+		assert.NoError(t, wait())
+	})
+
+	t.Run("Drain", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "gen.go"), nil, 0o600))
+		require.NoError(t, w.Drain(context.Background()))
+		assert.ErrorIs(t, wait(), context.DeadlineExceeded)
+	})
+}
@@ -5,15 +5,19 @@ import (
 	"os"
 
 	"github.com/stateful/runme/internal/cmd"
+	"github.com/stateful/runme/internal/sandbox"
 	"github.com/stateful/runme/internal/version"
 )
 
 func root() int {
+	// A sandboxed command is run by runme itself.
+	sandbox.Init()
+
 	root := cmd.Root()
 	root.Version = fmt.Sprintf("%s (%s) on %s", version.BuildVersion, version.Commit, version.BuildDate)
 	if err := root.Execute(); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err.Error())
-		return 1
+		return cmd.ExitCode(err)
 	}
 	return 0
 }